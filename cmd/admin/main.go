@@ -0,0 +1,158 @@
+// Entry point of the admin CLI for operator tasks that should not need a shell in
+// LocalStack or the database:
+//
+//	admin dlq queues
+//	admin dlq list -queue labor [-employee ID] [-from T] [-to T] [-error TEXT] [-limit N]
+//	admin dlq redrive -queue labor (-ids ID,ID | -all) [-employee ID] [-from T] [-to T] [-error TEXT] [-rate N]
+//
+// It uses the same configuration as the services and prints JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"checkin.service/internal/app"
+	"checkin.service/internal/config"
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/core/service"
+	"checkin.service/pkg/aws"
+	"checkin.service/pkg/database"
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
+	"github.com/rs/zerolog"
+)
+
+const usage = `usage:
+  admin dlq queues
+  admin dlq list -queue NAME [-employee ID] [-from T] [-to T] [-error TEXT] [-limit N]
+  admin dlq redrive -queue NAME (-ids ID,ID | -all) [-employee ID] [-from T] [-to T] [-error TEXT] [-rate N]`
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "dlq" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := runDLQ(os.Args[2], os.Args[3:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func runDLQ(command string, args []string) error {
+	flags := flag.NewFlagSet("dlq "+command, flag.ExitOnError)
+	queue := flags.String("queue", service.DeadLetterQueueLabor, "dead-letter queue: labor or email")
+	employeeID := flags.String("employee", "", "only messages of this employee")
+	from := flags.String("from", "", "only messages that failed at or after this time (RFC3339 or date)")
+	to := flags.String("to", "", "only messages that failed before this time (RFC3339 or date)")
+	errorText := flags.String("error", "", "only messages whose failure reason contains this text")
+	limit := flags.Int("limit", 100, "list: most messages to print")
+	ids := flags.String("ids", "", "redrive: comma-separated message IDs")
+	all := flags.Bool("all", false, "redrive: every message that matches the filters")
+	rate := flags.Int("rate", 0, "redrive: messages per second (default DLQ_REDRIVE_RATE)")
+	flags.Parse(args)
+
+	filter, err := parseFilter(*employeeID, *from, *to, *errorText)
+	if err != nil {
+		return err
+	}
+
+	deadLetters, closeFn, err := newDeadLetterService()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if *rate > 0 {
+		deadLetters.RedriveRate = *rate
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "queues":
+		return printJSON(map[string]any{"queues": deadLetters.Queues()})
+	case "list":
+		list, truncated, err := deadLetters.List(ctx, *queue, filter, *limit)
+		if err != nil {
+			return err
+		}
+		return printJSON(map[string]any{"queue": *queue, "messages": list, "truncated": truncated})
+	case "redrive":
+		var selected []string
+		if *ids != "" {
+			selected = strings.Split(*ids, ",")
+		}
+		result, err := deadLetters.Redrive(ctx, *queue, selected, *all, filter)
+		if result != nil {
+			if err := printJSON(result); err != nil {
+				return err
+			}
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+}
+
+// newDeadLetterService connects to the database and the broker like the API does.
+func newDeadLetterService() (*service.DeadLetterService, func(), error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not load configuration: %w", err)
+	}
+	// Keep stdout for the JSON output.
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+
+	db, err := database.NewInstrumentedConnection(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	awsCfg, err := aws.NewAWSConfig(context.Background(), cfg)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("unable to load SDK config: %w", err)
+	}
+
+	deadLetters, err := app.NewDeadLetterService(cfg, db, awsCfg)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return deadLetters, func() { db.Close() }, nil
+}
+
+func parseFilter(employeeID, from, to, errorText string) (model.DeadLetterFilter, error) {
+	filter := model.DeadLetterFilter{EmployeeID: employeeID, Error: errorText}
+	for _, bound := range []struct {
+		value string
+		dst   *time.Time
+	}{{from, &filter.From}, {to, &filter.To}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, bound.value)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("invalid time %q", bound.value)
+		}
+		*bound.dst = t
+	}
+	return filter, nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,173 @@
+// Entry point that runs the REST API, the labor worker and the email worker in one
+// process, connected through an in-memory broker instead of SQS. Emails are logged
+// instead of sent. Only Postgres and the legacy API are needed.
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	postgress "checkin.service/internal/adapters/Postgress"
+	"checkin.service/internal/adapters/memory"
+	"checkin.service/internal/adapters/queue"
+	"checkin.service/internal/app"
+	"checkin.service/internal/config"
+	"checkin.service/internal/core/service"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker"
+	"checkin.service/internal/worker/email"
+	"checkin.service/internal/worker/labor"
+	legacyAPI "checkin.service/internal/worker/legacyAPI"
+	"checkin.service/internal/worker/retry"
+	"checkin.service/pkg/database"
+	"checkin.service/pkg/logger"
+	"checkin.service/pkg/telemetry"
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not load configuration")
+	}
+
+	// Configure structured logging
+	logger.Setup(cfg.IsLocalDev)
+
+	// Configure OpenTelemetry Tracing
+	shutdownTracer, err := telemetry.InitTracer("checkin-all-in-one")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to init tracer")
+	}
+	defer func() {
+		_ = shutdownTracer(context.Background())
+	}()
+
+	// DB connection
+	db, err := database.NewInstrumentedConnection(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error opening database")
+	}
+	defer db.Close()
+	log.Info().Msg("Successfully connected to the database.")
+
+	// Initialize dependencies
+	broker := memory.NewBroker()
+	// Only the labor and email queues have a consumer in this process, so shift
+	// lifecycle events and compliance violations are not routed.
+	producer := ports.NewProducer(broker, ports.DefaultRoutes(ports.Destinations{
+		Labor: string(queue.Labor),
+		Email: string(queue.Email),
+	}))
+	services, err := app.NewServices(cfg, db, producer)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create services")
+	}
+
+	repo := postgress.NewWorkingTimeRepository(db)
+	laborProcessor := labor.NewProcessor(repo, legacyAPI.NewHTTPClient(cfg.LegacyAPIURL))
+	laborProcessor.LeaseTTL = time.Duration(cfg.ProcessingLeaseSeconds) * time.Second
+	laborProcessor.Retry = retry.NewExponential(
+		time.Duration(cfg.LaborRetryBaseSeconds)*time.Second,
+		time.Duration(cfg.LaborRetryMaxSeconds)*time.Second,
+		cfg.LaborMaxAttempts,
+	)
+	// The in-memory queues do not keep an employee's order, so records are never
+	// parked in the retry schedule here.
+	if cfg.LaborParkMaxAttempts > 0 {
+		log.Warn().Msg("LABOR_PARK_MAX_ATTEMPTS is ignored: the in-memory queues cannot keep an employee's order")
+	}
+	emailProcessor := email.NewProcessor(service.NewLogEmailService(), repo)
+	emailProcessor.LeaseTTL = time.Duration(cfg.ProcessingLeaseSeconds) * time.Second
+	emailProcessor.Retry = retry.NewExponential(
+		time.Duration(cfg.EmailRetryBaseSeconds)*time.Second,
+		time.Duration(cfg.EmailRetryMaxSeconds)*time.Second,
+		cfg.EmailMaxAttempts,
+	)
+
+	quarantine := postgress.NewQuarantineRepository(db)
+	services.Quarantine = service.NewQuarantineService(quarantine, broker, map[string]string{
+		string(queue.Labor): string(queue.Labor),
+		string(queue.Email): string(queue.Email),
+	})
+	services.Reconciliation.RepublishTo(broker, string(queue.Labor), string(queue.Email))
+
+	workers := []*worker.Worker{
+		newWorker(cfg, broker, queue.Labor, laborProcessor, quarantine),
+		newWorker(cfg, broker, queue.Email, emailProcessor, quarantine),
+	}
+
+	// Start workers and the reconciler
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Start(ctx)
+		}()
+	}
+	if cfg.ReconcileIntervalSeconds > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			services.Reconciliation.Run(ctx, time.Duration(cfg.ReconcileIntervalSeconds)*time.Second)
+		}()
+	}
+
+	// Start server in a goroutine
+	srv := &http.Server{
+		Addr:    ":" + cfg.ServerPort,
+		Handler: app.NewHandler(services),
+	}
+	go func() {
+		log.Info().Str("port", cfg.ServerPort).Msg("All-in-one service starting")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("listen")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Info().Msg("Shutting down...")
+
+	// Stop accepting requests first so no new messages are published, then drain the workers.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Server forced to shutdown")
+	}
+
+	cancel()
+	wg.Wait()
+
+	// Anything still queued in memory is lost; those records stay PENDING in the database
+	// until the reconciler republishes them after a restart.
+	log.Info().Msg("All-in-one service exited")
+}
+
+// newWorker creates a worker on queue q of broker with the worker settings from cfg.
+func newWorker(cfg config.Config, broker *memory.Broker, q queue.Queue, processor worker.Processor, quarantine repository.QuarantineRepository) *worker.Worker {
+	source := broker.Source(string(q))
+	source.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+
+	w := worker.NewWorker(source, processor)
+	w.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+	w.HeartbeatInterval = time.Duration(cfg.HeartbeatIntervalSeconds) * time.Second
+	w.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	w.MinConcurrency = cfg.WorkerMinConcurrency
+	w.MaxConcurrency = cfg.WorkerMaxConcurrency
+	w.LatencyTarget = time.Duration(cfg.WorkerLatencyTargetMillis) * time.Millisecond
+	w.Quarantine = quarantine
+	w.Queue = string(q)
+	return w
+}
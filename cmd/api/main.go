@@ -3,25 +3,22 @@ package main
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	sqsadapter "checkin.service/internal/adapters/SQS"
-	postgress "checkin.service/internal/adapters/postgress"
-	"checkin.service/internal/api"
+	"checkin.service/internal/adapters/queue"
+	"checkin.service/internal/app"
 	"checkin.service/internal/config"
-	checkin_service "checkin.service/internal/core/service"
 	"checkin.service/pkg/aws"
 	"checkin.service/pkg/database"
 	"checkin.service/pkg/logger"
 	"checkin.service/pkg/telemetry"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
 	"github.com/rs/zerolog/log"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
@@ -58,25 +55,34 @@ func main() {
 	}
 
 	// Initialize dependencies
-	sqsClient := sqs.NewFromConfig(awsCfg)
-	repo := postgress.NewWorkingTimeRepository(db)
-	producer := sqsadapter.NewSQSProducer(sqsClient, cfg.LaborSQSQueueURL, cfg.EmailSQSQueueURL)
-	coreService := checkin_service.NewCheckInService(repo, *producer)
-
-	// Setup router and server
-	router := api.NewRouter(*coreService)
-
-	// Middleware to inject logger with trace ID
-	loggerMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := r.Context()
-			ctx = logger.EnrichContextWithLogger(ctx)
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+	producer, err := queue.NewProducer(cfg, db, awsCfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create queue producer")
+	}
+	services, err := app.NewServices(cfg, db, producer)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create services")
+	}
+	services.DeadLetters, err = app.NewDeadLetterService(cfg, db, awsCfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create dead-letter service")
+	}
+	sender, err := queue.NewSender(cfg, db, awsCfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create message sender")
+	}
+	services.Quarantine = app.NewQuarantineService(cfg, db, sender)
+	services.Reconciliation.RepublishTo(sender, queue.Labor.Destination(cfg), queue.Email.Destination(cfg))
+
+	// Republish records whose messages got lost
+	reconcileCtx, stopReconciler := context.WithCancel(context.Background())
+	defer stopReconciler()
+	if cfg.ReconcileIntervalSeconds > 0 {
+		go services.Reconciliation.Run(reconcileCtx, time.Duration(cfg.ReconcileIntervalSeconds)*time.Second)
 	}
 
-	// Wrap the router with OpenTelemetry middleware to create spans for each request
-	handler := otelhttp.NewHandler(loggerMiddleware(router), "api")
+	// Setup router and server
+	handler := app.NewHandler(services)
 
 	serverAddr := ":" + cfg.ServerPort
 	srv := &http.Server{
@@ -97,6 +103,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info().Msg("Shutting down server...")
+	stopReconciler()
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the requests it is currently handling
@@ -106,5 +113,11 @@ func main() {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
 
+	if closer, ok := sender.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close message sender")
+		}
+	}
+
 	log.Info().Msg("Server exiting")
 }
@@ -2,20 +2,26 @@ package main
 
 import (
 	"context"
+	"io"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	postgress "checkin.service/internal/adapters/Postgress"
+	"checkin.service/internal/adapters/queue"
 	"checkin.service/internal/config"
+	"checkin.service/internal/ports"
 	"checkin.service/internal/worker"
 	"checkin.service/internal/worker/labor"
-	legacyAPI "checkin.service/internal/worker/legacyapi"
+	legacyAPI "checkin.service/internal/worker/legacyAPI"
+	"checkin.service/internal/worker/retry"
+	"checkin.service/internal/worker/schedule"
 	"checkin.service/pkg/aws"
 	"checkin.service/pkg/database"
 	"checkin.service/pkg/logger"
 	"checkin.service/pkg/telemetry"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/rs/zerolog/log"
 )
 
@@ -53,20 +59,69 @@ func main() {
 	}
 
 	// Initialize Dependencies
-	sqsClient := sqs.NewFromConfig(awsCfg)
-
 	repo := postgress.NewWorkingTimeRepository(db)
 
 	legacyClient := legacyAPI.NewHTTPClient(cfg.LegacyAPIURL)
 	processor := labor.NewProcessor(repo, legacyClient)
+	processor.LeaseTTL = time.Duration(cfg.ProcessingLeaseSeconds) * time.Second
+	processor.Retry = retry.NewExponential(
+		time.Duration(cfg.LaborRetryBaseSeconds)*time.Second,
+		time.Duration(cfg.LaborRetryMaxSeconds)*time.Second,
+		cfg.LaborMaxAttempts,
+	)
+
+	// Records whose retries ran out are parked and re-enqueued by the retry scheduler.
+	var scheduler *schedule.Scheduler
+	var sender ports.MessageSender
+	if cfg.LaborParkMaxAttempts > 0 {
+		sender, err = queue.NewSender(cfg, db, awsCfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create message sender")
+		}
+
+		schedules := postgress.NewRetryScheduleRepository(db)
+		processor.Schedule = schedules
+		processor.ParkRetry = retry.NewExponential(
+			time.Duration(cfg.LaborParkBaseSeconds)*time.Second,
+			time.Duration(cfg.LaborParkMaxSeconds)*time.Second,
+			cfg.LaborParkMaxAttempts,
+		)
+
+		scheduler = schedule.NewScheduler(schedules, sender, labor.ScheduleQueue, queue.Labor.Destination(cfg))
+		scheduler.Health = processor
+		scheduler.Rate = cfg.RetrySchedulerRate
+	}
+
+	source, err := queue.NewSource(cfg, db, awsCfg, queue.Labor)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create message source")
+	}
 
 	// Start Worker
 	ctx, cancel := context.WithCancel(context.Background())
-	app := worker.NewWorker(sqsClient, cfg.LaborSQSQueueURL, processor)
-
+	app := worker.NewWorker(source, processor)
+	app.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+	app.HeartbeatInterval = time.Duration(cfg.HeartbeatIntervalSeconds) * time.Second
+	app.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	app.MinConcurrency = cfg.WorkerMinConcurrency
+	app.MaxConcurrency = cfg.WorkerMaxConcurrency
+	app.LatencyTarget = time.Duration(cfg.WorkerLatencyTargetMillis) * time.Millisecond
+	app.Quarantine = postgress.NewQuarantineRepository(db)
+	app.Queue = string(queue.Labor)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		app.Start(ctx)
 	}()
+	if scheduler != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.Run(ctx)
+		}()
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -74,8 +129,21 @@ func main() {
 	<-quit
 	log.Info().Msg("Shutting down worker...")
 
-	// Cancel the context to signal the worker to stop polling.
+	// Cancel the context to signal the worker to stop polling, then wait for
+	// in-flight messages to drain before closing the database and tracer.
 	cancel()
+	wg.Wait()
+
+	if closer, ok := source.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close message source")
+		}
+	}
+	if closer, ok := sender.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close message sender")
+		}
+	}
 
 	log.Info().Msg("Worker exited gracefully")
 }
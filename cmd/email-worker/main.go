@@ -2,21 +2,24 @@ package main
 
 import (
 	"context"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	postgress "checkin.service/internal/adapters/Postgress"
+	"checkin.service/internal/adapters/queue"
 	"checkin.service/internal/config"
 	"checkin.service/internal/core/service"
 	"checkin.service/internal/worker"
 	"checkin.service/internal/worker/email"
+	"checkin.service/internal/worker/retry"
 	"checkin.service/pkg/aws"
 	"checkin.service/pkg/database"
 	"checkin.service/pkg/logger"
 	"checkin.service/pkg/telemetry"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/rs/zerolog/log"
 )
 
@@ -54,17 +57,37 @@ func main() {
 	}
 
 	// Initialize Dependencies
-	sqsClient := sqs.NewFromConfig(awsCfg)
 	sesClient := ses.NewFromConfig(awsCfg)
 	repo := postgress.NewWorkingTimeRepository(db)
 	emailService := service.NewSESEmailService(sesClient, "checkOut@checkout-service.com")
 	processor := email.NewProcessor(emailService, repo)
+	processor.LeaseTTL = time.Duration(cfg.ProcessingLeaseSeconds) * time.Second
+	processor.Retry = retry.NewExponential(
+		time.Duration(cfg.EmailRetryBaseSeconds)*time.Second,
+		time.Duration(cfg.EmailRetryMaxSeconds)*time.Second,
+		cfg.EmailMaxAttempts,
+	)
+
+	source, err := queue.NewSource(cfg, db, awsCfg, queue.Email)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create message source")
+	}
 
 	// Start Worker
 	ctx, cancel := context.WithCancel(context.Background())
-	app := worker.NewWorker(sqsClient, cfg.EmailSQSQueueURL, processor)
+	app := worker.NewWorker(source, processor)
+	app.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+	app.HeartbeatInterval = time.Duration(cfg.HeartbeatIntervalSeconds) * time.Second
+	app.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	app.MinConcurrency = cfg.WorkerMinConcurrency
+	app.MaxConcurrency = cfg.WorkerMaxConcurrency
+	app.LatencyTarget = time.Duration(cfg.WorkerLatencyTargetMillis) * time.Millisecond
+	app.Quarantine = postgress.NewQuarantineRepository(db)
+	app.Queue = string(queue.Email)
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		app.Start(ctx)
 	}()
 
@@ -74,8 +97,16 @@ func main() {
 	<-quit
 	log.Info().Msg("Shutting down worker...")
 
-	// Cancel the context to signal the worker to stop polling.
+	// Cancel the context to signal the worker to stop polling, then wait for
+	// in-flight messages to drain before closing the database and tracer.
 	cancel()
+	<-stopped
+
+	if closer, ok := source.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close message source")
+		}
+	}
 
 	log.Info().Msg("Worker exited gracefully")
 }
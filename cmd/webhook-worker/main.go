@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	postgress "checkin.service/internal/adapters/Postgress"
+	"checkin.service/internal/adapters/queue"
+	"checkin.service/internal/config"
+	"checkin.service/internal/worker"
+	"checkin.service/internal/worker/webhook"
+	"checkin.service/pkg/aws"
+	"checkin.service/pkg/database"
+	"checkin.service/pkg/logger"
+	"checkin.service/pkg/telemetry"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not load configuration")
+	}
+
+	// Configure structured logging
+	logger.Setup(cfg.IsLocalDev)
+
+	// Configure OpenTelemetry Tracing
+	shutdownTracer, err := telemetry.InitTracer("webhook-worker")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to init tracer")
+	}
+	defer func() {
+		_ = shutdownTracer(context.Background())
+	}()
+
+	// DB connection
+	db, err := database.NewInstrumentedConnection(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error opening database")
+	}
+	defer db.Close()
+	log.Info().Msg("Successfully connected to the database.")
+
+	// AWS SDK Config
+	awsCfg, err := aws.NewAWSConfig(context.Background(), cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to load SDK config")
+	}
+
+	// Initialize Dependencies
+	repo := postgress.NewWebhookRepository(db)
+	processor := webhook.NewProcessor(repo)
+	dispatcher := webhook.NewDispatcher(repo, time.Duration(cfg.WebhookTimeoutSeconds)*time.Second, cfg.WebhookAllowPrivateNetworks)
+	dispatcher.Retry.MaxAttempts = cfg.WebhookMaxAttempts
+
+	source, err := queue.NewSource(cfg, db, awsCfg, queue.Events)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create message source")
+	}
+
+	// Start the worker, which turns events into deliveries, and the dispatcher,
+	// which sends them.
+	ctx, cancel := context.WithCancel(context.Background())
+	app := worker.NewWorker(source, processor)
+	app.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+	app.HeartbeatInterval = time.Duration(cfg.HeartbeatIntervalSeconds) * time.Second
+	app.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	app.MinConcurrency = cfg.WorkerMinConcurrency
+	app.MaxConcurrency = cfg.WorkerMaxConcurrency
+	app.LatencyTarget = time.Duration(cfg.WorkerLatencyTargetMillis) * time.Millisecond
+	app.Quarantine = postgress.NewQuarantineRepository(db)
+	app.Queue = string(queue.Events)
+
+	var stopped sync.WaitGroup
+	stopped.Add(2)
+	go func() {
+		defer stopped.Done()
+		app.Start(ctx)
+	}()
+	go func() {
+		defer stopped.Done()
+		dispatcher.Run(ctx)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	<-quit
+	log.Info().Msg("Shutting down worker...")
+
+	// Cancel the context to stop polling, then wait for in-flight messages and
+	// deliveries to finish before closing the database and tracer.
+	cancel()
+	stopped.Wait()
+
+	if closer, ok := source.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close message source")
+		}
+	}
+
+	log.Info().Msg("Worker exited gracefully")
+}
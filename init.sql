@@ -12,4 +12,205 @@ CREATE TABLE working_times (
 );
 
 CREATE INDEX idx_labor_pending ON working_times(labor_status) WHERE labor_status = 'PENDING';
-CREATE INDEX idx_email_pending ON working_times(email_status) WHERE email_status = 'PENDING';
\ No newline at end of file
+CREATE INDEX idx_email_pending ON working_times(email_status) WHERE email_status = 'PENDING';
+CREATE TABLE job_segments (
+    id BIGSERIAL PRIMARY KEY,
+    working_time_id BIGINT NOT NULL REFERENCES working_times(id),
+    employee_id VARCHAR(50) NOT NULL,
+    job_code VARCHAR(50) NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP,
+    minutes_worked NUMERIC(8, 2),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_job_segments_working_time ON job_segments(working_time_id);
+CREATE INDEX idx_job_segments_employee_start ON job_segments(employee_id, start_time);
+
+CREATE TABLE timesheets (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    total_hours NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    submitted_at TIMESTAMP,
+    reviewed_by VARCHAR(50),
+    reviewed_at TIMESTAMP,
+    rejection_reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, period_start)
+);
+
+CREATE INDEX idx_timesheets_status ON timesheets(status);
+CREATE INDEX idx_working_times_employee_clock_in ON working_times(employee_id, clock_in_time);
+
+CREATE TABLE disputes (
+    id BIGSERIAL PRIMARY KEY,
+    working_time_id BIGINT NOT NULL REFERENCES working_times(id),
+    employee_id VARCHAR(50) NOT NULL,
+    comment TEXT NOT NULL,
+    proposed_clock_in_time TIMESTAMP,
+    proposed_clock_out_time TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    reviewed_by VARCHAR(50),
+    review_comment TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_disputes_open ON disputes(status) WHERE status = 'OPEN';
+CREATE INDEX idx_disputes_employee ON disputes(employee_id);
+
+CREATE TABLE absences (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    absence_type VARCHAR(30) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    hours_credited NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, absence_type, start_date)
+);
+
+CREATE INDEX idx_absences_employee_dates ON absences(employee_id, start_date, end_date);
+
+ALTER TABLE working_times ADD COLUMN flagged_absence_id BIGINT REFERENCES absences(id);
+
+CREATE TABLE compliance_violations (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    working_time_id BIGINT REFERENCES working_times(id),
+    rule VARCHAR(30) NOT NULL,
+    measured_hours NUMERIC(7, 2) NOT NULL,
+    limit_hours NUMERIC(7, 2) NOT NULL,
+    detail TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_compliance_violations_employee ON compliance_violations(employee_id, occurred_at);
+
+-- Processing leases: a worker sets the channel status to PROCESSING and owns the
+-- record until the lease expires, so only one worker acts on a record at a time.
+ALTER TABLE working_times
+    ADD COLUMN labor_lease_owner VARCHAR(100),
+    ADD COLUMN labor_lease_expires_at TIMESTAMP,
+    ADD COLUMN email_lease_owner VARCHAR(100),
+    ADD COLUMN email_lease_expires_at TIMESTAMP;
+
+-- Postgres queue backend (QUEUE_BACKEND=postgres) for sites without AWS.
+-- Workers claim visible jobs with FOR UPDATE SKIP LOCKED and hide them until
+-- visible_at; lease_token identifies the current delivery.
+CREATE TABLE queue_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    visible_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    receive_count INT NOT NULL DEFAULT 0,
+    lease_token VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_queue_jobs_visible ON queue_jobs(queue, visible_at);
+
+CREATE TABLE queue_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL,
+    queue VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    receive_count INT NOT NULL,
+    reason TEXT NOT NULL,
+    failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_queue_dead_letters_queue ON queue_dead_letters(queue, failed_at);
+
+-- Incremented on every correction of a shift; events carry it so FIFO queues can
+-- deduplicate per working time and version.
+ALTER TABLE working_times ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- A voided shift (e.g. a mistaken tap) is kept for the audit trail but no longer counts.
+ALTER TABLE working_times ADD COLUMN voided_at TIMESTAMP;
+ALTER TABLE working_times ADD COLUMN voided_by VARCHAR(50);
+ALTER TABLE working_times ADD COLUMN void_reason TEXT;
+
+-- Outbound webhooks. A delivery row is created per subscription and event; the
+-- deliveries of a subscription are its delivery log.
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    event_types JSONB NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id),
+    event_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INT,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+
+-- Retry schedule. Messages whose retries ran out during an outage are parked here
+-- and sent to their queue again later, all jobs of one employee in parking order.
+-- claimed_until is set while the scheduler is sending a job.
+CREATE TABLE retry_schedule (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(50) NOT NULL,
+    working_time_id BIGINT NOT NULL REFERENCES working_times(id),
+    employee_id VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 1,
+    next_attempt_at TIMESTAMP NOT NULL,
+    parked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    claimed_until TIMESTAMP,
+    last_error TEXT,
+    UNIQUE (queue, working_time_id)
+);
+
+CREATE INDEX idx_retry_schedule_employee ON retry_schedule(queue, employee_id, parked_at);
+
+-- Quarantine. Messages a processor rejected as unrecoverable (e.g. a malformed body)
+-- are stored here and removed from their queue, instead of being redelivered until
+-- they reach the DLQ. After a fix they can be replayed to their queue.
+CREATE TABLE quarantined_messages (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(50) NOT NULL,
+    processor VARCHAR(100) NOT NULL,
+    message_id VARCHAR(200) NOT NULL,
+    employee_id VARCHAR(50),
+    body TEXT NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL,
+    receive_count INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'QUARANTINED',
+    quarantined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    UNIQUE (queue, message_id)
+);
+
+CREATE INDEX idx_quarantined_messages_status ON quarantined_messages(status, queue, quarantined_at);
+
+-- Reconciler. Set when it republished the event of a channel that was stuck, so the
+-- channel is republished at most once per SLA, however many API instances run it.
+ALTER TABLE working_times
+    ADD COLUMN labor_republished_at TIMESTAMP,
+    ADD COLUMN email_republished_at TIMESTAMP;
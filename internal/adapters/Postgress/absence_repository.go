@@ -0,0 +1,87 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+const absenceColumns = `id, employee_id, absence_type, start_date, end_date, hours_credited, status`
+
+// AbsenceRepository is the PostgreSQL implementation of repository.AbsenceRepository.
+type AbsenceRepository struct {
+	DB *sql.DB
+}
+
+// NewAbsenceRepository create new instance
+func NewAbsenceRepository(db *sql.DB) repository.AbsenceRepository {
+	return &AbsenceRepository{DB: db}
+}
+
+// UpsertAbsence stores an absence. Re-importing the same employee, type and start
+// date updates the existing record instead of duplicating it.
+func (r *AbsenceRepository) UpsertAbsence(ctx context.Context, a *model.Absence) (int64, error) {
+	var id int64
+	query := `INSERT INTO absences (employee_id, absence_type, start_date, end_date, hours_credited, status)
+              VALUES ($1, $2, $3, $4, $5, $6)
+              ON CONFLICT (employee_id, absence_type, start_date) DO UPDATE
+              SET end_date = EXCLUDED.end_date,
+                  hours_credited = EXCLUDED.hours_credited,
+                  status = EXCLUDED.status
+              RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, query, a.EmployeeID, a.Type, a.StartDate, a.EndDate, a.HoursCredited, a.Status).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListAbsences returns the absences of an employee overlapping [from, to).
+// An empty employeeID lists all employees.
+func (r *AbsenceRepository) ListAbsences(ctx context.Context, employeeID string, from, to time.Time) ([]model.Absence, error) {
+	query := `SELECT ` + absenceColumns + ` FROM absences
+              WHERE ($1 = '' OR employee_id = $1) AND start_date < $3 AND end_date >= $2::date
+              ORDER BY employee_id, start_date`
+
+	rows, err := r.DB.QueryContext(ctx, query, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Absence
+	for rows.Next() {
+		var a model.Absence
+		if err := rows.Scan(&a.ID, &a.EmployeeID, &a.Type, &a.StartDate, &a.EndDate, &a.HoursCredited, &a.Status); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, rows.Err()
+}
+
+// FindApprovedAbsence returns the approved absence covering the day of at, or nil.
+func (r *AbsenceRepository) FindApprovedAbsence(ctx context.Context, employeeID string, at time.Time) (*model.Absence, error) {
+	query := `SELECT ` + absenceColumns + ` FROM absences
+              WHERE employee_id = $1 AND status = $2 AND $3::date BETWEEN start_date AND end_date
+              ORDER BY start_date
+              LIMIT 1`
+
+	a := &model.Absence{}
+	err := r.DB.QueryRowContext(ctx, query, employeeID, model.StatusAbsenceApproved, at).Scan(
+		&a.ID, &a.EmployeeID, &a.Type, &a.StartDate, &a.EndDate, &a.HoursCredited, &a.Status,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
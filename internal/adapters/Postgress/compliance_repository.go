@@ -0,0 +1,63 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+// ComplianceRepository is the PostgreSQL implementation of repository.ComplianceRepository.
+type ComplianceRepository struct {
+	DB *sql.DB
+}
+
+// NewComplianceRepository create new instance
+func NewComplianceRepository(db *sql.DB) repository.ComplianceRepository {
+	return &ComplianceRepository{DB: db}
+}
+
+// RecordViolation stores a compliance violation.
+func (r *ComplianceRepository) RecordViolation(ctx context.Context, v *model.ComplianceViolation) (int64, error) {
+	var id int64
+	query := `INSERT INTO compliance_violations (employee_id, working_time_id, rule, measured_hours, limit_hours, detail, occurred_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, query, v.EmployeeID, v.WorkingTimeID, v.Rule,
+		v.MeasuredHours, v.LimitHours, v.Detail, v.OccurredAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListViolations returns violations that occurred in [from, to), newest first.
+// Empty employeeID and rule filters match everything.
+func (r *ComplianceRepository) ListViolations(ctx context.Context, employeeID string, rule model.ComplianceRule, from, to time.Time) ([]model.ComplianceViolation, error) {
+	query := `SELECT id, employee_id, COALESCE(working_time_id, 0), rule, measured_hours, limit_hours, detail, occurred_at
+              FROM compliance_violations
+              WHERE ($1 = '' OR employee_id = $1) AND ($2 = '' OR rule = $2)
+                AND occurred_at >= $3 AND occurred_at < $4
+              ORDER BY occurred_at DESC`
+
+	rows, err := r.DB.QueryContext(ctx, query, employeeID, rule, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.ComplianceViolation
+	for rows.Next() {
+		var v model.ComplianceViolation
+		err := rows.Scan(&v.ID, &v.EmployeeID, &v.WorkingTimeID, &v.Rule, &v.MeasuredHours, &v.LimitHours, &v.Detail, &v.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
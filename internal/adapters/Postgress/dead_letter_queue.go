@@ -0,0 +1,116 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/ports"
+)
+
+// DeadLetterQueue implements ports.DeadLetterQueue on the queue_dead_letters table.
+type DeadLetterQueue struct {
+	DB    *sql.DB
+	queue string
+}
+
+func NewDeadLetterQueue(db *sql.DB, queue string) *DeadLetterQueue {
+	return &DeadLetterQueue{DB: db, queue: queue}
+}
+
+// List returns the oldest dead-lettered jobs of the queue.
+func (q *DeadLetterQueue) List(ctx context.Context, max int) ([]ports.DeadLetter, error) {
+	query := `SELECT id, body, attributes, receive_count, reason, failed_at
+              FROM queue_dead_letters
+              WHERE queue = $1
+              ORDER BY failed_at, id
+              LIMIT $2`
+
+	rows, err := q.DB.QueryContext(ctx, query, q.queue, max)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ports.DeadLetter
+	for rows.Next() {
+		var d ports.DeadLetter
+		var id int64
+		var body string
+		var attributes []byte
+		if err := rows.Scan(&id, &body, &attributes, &d.ReceiveCount, &d.Reason, &d.FailedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(attributes, &d.Attributes); err != nil {
+			return nil, err
+		}
+		d.ID = strconv.FormatInt(id, 10)
+		d.Body = []byte(body)
+		result = append(result, d)
+	}
+
+	return result, rows.Err()
+}
+
+// Redrive moves each job back to queue_jobs in a single statement, so it is never
+// in both tables or in neither. beforeSend runs in the same transaction, after the
+// job was found and before a worker can see it.
+func (q *DeadLetterQueue) Redrive(ctx context.Context, ids []string, perSecond int, beforeSend func(id string) error) ([]string, error) {
+	interval := time.Second / time.Duration(max(perSecond, 1))
+	var moved []string
+	for i, id := range ids {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return moved, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		ok, err := q.redrive(ctx, n, id, beforeSend)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved = append(moved, id)
+		}
+	}
+	return moved, nil
+}
+
+// redrive moves one job back to queue_jobs. It reports false if the job is not in the
+// dead-letter queue.
+func (q *DeadLetterQueue) redrive(ctx context.Context, n int64, id string, beforeSend func(id string) error) (bool, error) {
+	query := `WITH moved AS (
+                  DELETE FROM queue_dead_letters WHERE id = $1 AND queue = $2
+                  RETURNING queue, body, attributes
+              )
+              INSERT INTO queue_jobs (queue, body, attributes)
+              SELECT queue, body, attributes FROM moved`
+
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, n, q.queue)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return false, err
+	}
+	if beforeSend != nil {
+		if err := beforeSend(id); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
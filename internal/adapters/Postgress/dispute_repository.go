@@ -0,0 +1,172 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+const disputeColumns = `id, working_time_id, employee_id, comment, proposed_clock_in_time, proposed_clock_out_time,
+                        status, COALESCE(reviewed_by, ''), COALESCE(review_comment, ''), reviewed_at, created_at`
+
+// DisputeRepository is the PostgreSQL implementation of repository.DisputeRepository.
+type DisputeRepository struct {
+	DB *sql.DB
+}
+
+// NewDisputeRepository create new instance
+func NewDisputeRepository(db *sql.DB) repository.DisputeRepository {
+	return &DisputeRepository{DB: db}
+}
+
+// CreateDispute stores a new OPEN dispute.
+func (r *DisputeRepository) CreateDispute(ctx context.Context, d *model.Dispute) (int64, error) {
+	var id int64
+	query := `INSERT INTO disputes (working_time_id, employee_id, comment, proposed_clock_in_time, proposed_clock_out_time, status)
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, query, d.WorkingTimeID, d.EmployeeID, d.Comment,
+		d.ProposedClockInTime, d.ProposedClockOutTime, model.StatusDisputeOpen).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetDispute fetches a dispute by its ID.
+func (r *DisputeRepository) GetDispute(ctx context.Context, id int64) (*model.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+
+	return scanDispute(r.DB.QueryRowContext(ctx, query, id))
+}
+
+// ListDisputes returns disputes, oldest first. Empty filters match everything.
+func (r *DisputeRepository) ListDisputes(ctx context.Context, employeeID string, status model.DisputeStatus) ([]model.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes
+              WHERE ($1 = '' OR employee_id = $1) AND ($2 = '' OR status = $2)
+              ORDER BY created_at`
+
+	rows, err := r.DB.QueryContext(ctx, query, employeeID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Dispute
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *d)
+	}
+
+	return result, rows.Err()
+}
+
+// ResolveDispute closes an OPEN dispute. It reports false if the dispute was already resolved.
+func (r *DisputeRepository) ResolveDispute(ctx context.Context, id int64, status model.DisputeStatus, reviewer, comment string, reviewedAt time.Time) (bool, error) {
+	query := `UPDATE disputes
+              SET status = $1,
+                  reviewed_by = $2,
+                  review_comment = NULLIF($3, ''),
+                  reviewed_at = $4
+              WHERE id = $5 AND status = $6`
+
+	res, err := r.DB.ExecContext(ctx, query, status, reviewer, comment, reviewedAt, id, model.StatusDisputeOpen)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// CorrectDispute resolves an OPEN dispute as CORRECTED and overwrites the clock times
+// of its shift in one transaction. The shift's version is bumped and its labor status
+// reset, so the correction is sent to the legacy system again. Any labor lease is
+// dropped, so a worker still busy with the old times cannot mark the corrected record
+// as completed. The job segments are fitted to the new times: the first starts at
+// clockIn, the last ends at clockOut, and segments outside the shift are removed.
+// It reports false, changing nothing, if the dispute was already resolved.
+func (r *DisputeRepository) CorrectDispute(ctx context.Context, id, workingTimeID int64, clockIn, clockOut time.Time, hoursWorked float64, reviewer, comment string, reviewedAt time.Time) (bool, error) {
+	correct := `UPDATE working_times
+                SET clock_in_time = $1,
+                    clock_out_time = $2,
+                    hours_worked = $3,
+                    labor_status = $4,
+                    labor_retry_count = 0,
+                    labor_lease_owner = NULL,
+                    labor_lease_expires_at = NULL,
+                    version = version + 1
+                WHERE id = $5`
+
+	fitSegments := `WITH ordered AS (
+                        SELECT id, start_time, end_time,
+                               ROW_NUMBER() OVER (ORDER BY start_time, id) AS n,
+                               COUNT(*) OVER () AS total
+                        FROM job_segments
+                        WHERE working_time_id = $1
+                    )
+                    UPDATE job_segments s
+                    SET start_time = CASE WHEN o.n = 1 THEN $2::timestamp ELSE GREATEST(o.start_time, $2::timestamp) END,
+                        end_time = CASE WHEN o.n = o.total THEN $3::timestamp ELSE LEAST(COALESCE(o.end_time, $3::timestamp), $3::timestamp) END
+                    FROM ordered o
+                    WHERE s.id = o.id`
+
+	dropEmpty := `DELETE FROM job_segments WHERE working_time_id = $1 AND end_time <= start_time`
+
+	recount := `UPDATE job_segments
+                SET minutes_worked = EXTRACT(EPOCH FROM (end_time - start_time)) / 60
+                WHERE working_time_id = $1`
+
+	resolve := `UPDATE disputes
+                SET status = $1,
+                    reviewed_by = $2,
+                    review_comment = NULLIF($3, ''),
+                    reviewed_at = $4
+                WHERE id = $5 AND status = $6`
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, correct, clockIn, clockOut, hoursWorked, model.StatusWorkingPending, workingTimeID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, fitSegments, workingTimeID, clockIn, clockOut); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, dropEmpty, workingTimeID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, recount, workingTimeID); err != nil {
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx, resolve, model.StatusDisputeCorrected, reviewer, comment, reviewedAt, id, model.StatusDisputeOpen)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func scanDispute(row rowScanner) (*model.Dispute, error) {
+	d := &model.Dispute{}
+	err := row.Scan(&d.ID, &d.WorkingTimeID, &d.EmployeeID, &d.Comment, &d.ProposedClockInTime, &d.ProposedClockOutTime,
+		&d.Status, &d.ReviewedBy, &d.ReviewComment, &d.ReviewedAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,93 @@
+package postgress
+
+import (
+	"context"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartJobSegment opens a new job segment on a shift.
+func (r *WorkingTimeRepository) StartJobSegment(ctx context.Context, workingTimeID int64, employeeID, jobCode string, start time.Time) (int64, error) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("app.employeeId", employeeID))
+
+	var id int64
+	query := `INSERT INTO job_segments (working_time_id, employee_id, job_code, start_time)
+              VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, query, workingTimeID, employeeID, jobCode, start).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// closeJobSegmentQuery closes the open job segment of shift $2 at time $1.
+const closeJobSegmentQuery = `UPDATE job_segments
+              SET end_time = $1,
+                  minutes_worked = EXTRACT(EPOCH FROM ($1::timestamp - start_time)) / 60
+              WHERE working_time_id = $2 AND end_time IS NULL`
+
+// CloseJobSegment closes the open job segment of a shift, if there is one.
+func (r *WorkingTimeRepository) CloseJobSegment(ctx context.Context, workingTimeID int64, end time.Time) error {
+	_, err := r.DB.ExecContext(ctx, closeJobSegmentQuery, end, workingTimeID)
+
+	return err
+}
+
+// ListJobSegments returns the job segments of a shift in the order they were worked.
+func (r *WorkingTimeRepository) ListJobSegments(ctx context.Context, workingTimeID int64) ([]model.JobSegment, error) {
+	query := `SELECT id, working_time_id, employee_id, job_code, start_time, end_time, COALESCE(minutes_worked, 0)
+              FROM job_segments
+              WHERE working_time_id = $1
+              ORDER BY start_time`
+
+	rows, err := r.DB.QueryContext(ctx, query, workingTimeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []model.JobSegment
+	for rows.Next() {
+		var s model.JobSegment
+		if err := rows.Scan(&s.ID, &s.WorkingTimeID, &s.EmployeeID, &s.JobCode, &s.StartTime, &s.EndTime, &s.MinutesWorked); err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+
+	return segments, rows.Err()
+}
+
+// GetJobLabor sums the minutes of closed job segments per employee and job in [from, to).
+// An empty employeeID reports on all employees.
+func (r *WorkingTimeRepository) GetJobLabor(ctx context.Context, employeeID string, from, to time.Time) ([]model.JobLabor, error) {
+	query := `SELECT employee_id, job_code, SUM(minutes_worked)
+              FROM job_segments
+              WHERE end_time IS NOT NULL
+                AND start_time >= $1 AND start_time < $2
+                AND ($3 = '' OR employee_id = $3)
+              GROUP BY employee_id, job_code
+              ORDER BY employee_id, job_code`
+
+	rows, err := r.DB.QueryContext(ctx, query, from, to, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []model.JobLabor
+	for rows.Next() {
+		var l model.JobLabor
+		if err := rows.Scan(&l.EmployeeID, &l.JobCode, &l.MinutesWorked); err != nil {
+			return nil, err
+		}
+		report = append(report, l)
+	}
+
+	return report, rows.Err()
+}
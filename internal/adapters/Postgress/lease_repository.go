@@ -0,0 +1,130 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"checkin.service/internal/core/model"
+)
+
+// AcquireLaborLease takes the labor processing lease of a record by moving it from
+// PENDING (or PARKED, when the retry schedule sends it again) to PROCESSING. A
+// PROCESSING record whose lease expired is reclaimed. owner must be unique to this
+// acquisition, see worker.NewLeaseToken. It returns the record's labor retry count as
+// of taking the lease, and reports false if another worker holds a live lease, the
+// record is finished, or it is no longer at version because it was corrected.
+func (r *WorkingTimeRepository) AcquireLaborLease(ctx context.Context, id int64, version int, owner string, ttl time.Duration) (int, bool, error) {
+	query := `UPDATE working_times
+              SET labor_status = $1,
+                  labor_lease_owner = $2,
+                  labor_lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+              WHERE id = $4 AND version = $7
+                AND (labor_status IN ($5, $6) OR (labor_status = $1 AND labor_lease_expires_at < NOW()))
+              RETURNING labor_retry_count`
+
+	row := r.DB.QueryRowContext(ctx, query, model.StatusWorkingProcessing, owner, ttl.Milliseconds(), id,
+		model.StatusWorkingPending, model.StatusWorkingParked, version)
+	return scanLease(row)
+}
+
+// ReleaseLaborLease records the outcome of a labor attempt and drops the lease.
+// It reports false if the lease is no longer held by owner.
+func (r *WorkingTimeRepository) ReleaseLaborLease(ctx context.Context, id int64, owner string, status model.WorkingTimeStatus, retryCount int) (bool, error) {
+	query := `UPDATE working_times
+              SET labor_status = $1,
+                  labor_retry_count = $2,
+                  labor_lease_owner = NULL,
+                  labor_lease_expires_at = NULL
+              WHERE id = $3 AND labor_lease_owner = $4`
+
+	res, err := r.DB.ExecContext(ctx, query, status, retryCount, id, owner)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// AcquireEmailLease takes the email processing lease of a record, see AcquireLaborLease.
+func (r *WorkingTimeRepository) AcquireEmailLease(ctx context.Context, id int64, owner string, ttl time.Duration) (int, bool, error) {
+	query := `UPDATE working_times
+              SET email_status = $1,
+                  email_lease_owner = $2,
+                  email_lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+              WHERE id = $4
+                AND (email_status = $5 OR (email_status = $1 AND email_lease_expires_at < NOW()))
+              RETURNING email_retry_count`
+
+	row := r.DB.QueryRowContext(ctx, query, model.StatusEmailProcessing, owner, ttl.Milliseconds(), id, model.StatusEmailPending)
+	return scanLease(row)
+}
+
+// scanLease reads the retry count returned by a lease acquisition; no row means the
+// lease was not acquired.
+func scanLease(row *sql.Row) (int, bool, error) {
+	var retryCount int
+	err := row.Scan(&retryCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return retryCount, true, nil
+}
+
+// ReleaseEmailLease records the outcome of an email attempt and drops the lease.
+// It reports false if the lease is no longer held by owner.
+func (r *WorkingTimeRepository) ReleaseEmailLease(ctx context.Context, id int64, owner string, status model.EmailStatus, retryCount int) (bool, error) {
+	query := `UPDATE working_times
+              SET email_status = $1,
+                  email_retry_count = $2,
+                  email_lease_owner = NULL,
+                  email_lease_expires_at = NULL
+              WHERE id = $3 AND email_lease_owner = $4`
+
+	res, err := r.DB.ExecContext(ctx, query, status, retryCount, id, owner)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ResetLaborRetries gives a FAILED or PENDING record a fresh labor retry budget, so a
+// redriven message is processed again. It reports false if the record is being
+// processed, finished or parked.
+func (r *WorkingTimeRepository) ResetLaborRetries(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE working_times
+              SET labor_status = $1,
+                  labor_retry_count = 0
+              WHERE id = $2 AND labor_status IN ($1, $3)`
+
+	res, err := r.DB.ExecContext(ctx, query, model.StatusWorkingPending, id, model.StatusWorkingFailed)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ResetEmailRetries gives a FAILED or PENDING record a fresh email retry budget, see ResetLaborRetries.
+func (r *WorkingTimeRepository) ResetEmailRetries(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE working_times
+              SET email_status = $1,
+                  email_retry_count = 0
+              WHERE id = $2 AND email_status IN ($1, $3)`
+
+	res, err := r.DB.ExecContext(ctx, query, model.StatusEmailPending, id, model.StatusEmailFailed)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -39,7 +39,8 @@ func (r *WorkingTimeRepository) CreateCheckIn(ctx context.Context, employeeID st
 	return id, nil
 }
 
-// UpdateCheckOut do checkout.
+// UpdateCheckOut do checkout. The shift's open job segment is closed in the same
+// transaction, so a shift is never left checked out with an open segment.
 func (r *WorkingTimeRepository) UpdateCheckOut(ctx context.Context, id int64, clockOut time.Time, hoursWorked float64, employeeID string) error {
 	trace.SpanFromContext(ctx).SetAttributes(attribute.String("app.employeeId", employeeID))
 	trace.SpanFromContext(ctx).SetAttributes(attribute.String("app.employee_id", employeeID))
@@ -49,9 +50,20 @@ func (r *WorkingTimeRepository) UpdateCheckOut(ctx context.Context, id int64, cl
                   labor_status = $3
               WHERE id = $4`
 
-	_, err := r.DB.ExecContext(ctx, query, clockOut, hoursWorked, model.StatusWorkingPending, id)
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	return err
+	if _, err := tx.ExecContext(ctx, query, clockOut, hoursWorked, model.StatusWorkingPending, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, closeJobSegmentQuery, clockOut, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // UpdateLaborStatus updates the status and retry count for a labor-related job.
@@ -76,14 +88,14 @@ func (r *WorkingTimeRepository) FindLastCheckIn(ctx context.Context, employeeID
 	var clockIn time.Time
 	wt := &model.WorkingTime{EmployeeID: employeeID}
 
-	query := `SELECT id, clock_in_time, labor_status, labor_retry_count
+	query := `SELECT id, clock_in_time, labor_status, labor_retry_count, version
               FROM working_times
-              WHERE employee_id = $1 AND clock_out_time IS NULL
+              WHERE employee_id = $1 AND clock_out_time IS NULL AND voided_at IS NULL
               ORDER BY clock_in_time DESC
               LIMIT 1`
 
 	row := r.DB.QueryRowContext(ctx, query, employeeID)
-	err := row.Scan(&wt.ID, &clockIn, &wt.LaborStatus, &wt.LaborRetryCount)
+	err := row.Scan(&wt.ID, &clockIn, &wt.LaborStatus, &wt.LaborRetryCount, &wt.Version)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -110,12 +122,14 @@ func (r *WorkingTimeRepository) GetStatus(ctx context.Context, id int64) (model.
 
 // GetCheckInOut fetches a complete working_times record by its ID.
 func (r *WorkingTimeRepository) GetCheckInOut(ctx context.Context, id int64) (*model.WorkingTime, error) {
-	query := `SELECT id, employee_id, labor_status, labor_retry_count, email_status, email_retry_count, hours_worked 
+	query := `SELECT id, employee_id, labor_status, labor_retry_count, email_status, email_retry_count, COALESCE(hours_worked, 0),
+	                 clock_in_time, clock_out_time, version, voided_at, COALESCE(voided_by, ''), COALESCE(void_reason, '')
 	          FROM working_times WHERE id = $1`
 
 	wt := &model.WorkingTime{}
 	err := r.DB.QueryRowContext(ctx, query, id).Scan(
 		&wt.ID, &wt.EmployeeID, &wt.LaborStatus, &wt.LaborRetryCount, &wt.EmailStatus, &wt.EmailRetryCount, &wt.HoursWorked,
+		&wt.ClockInTime, &wt.ClockOutTime, &wt.Version, &wt.VoidedAt, &wt.VoidedBy, &wt.VoidReason,
 	)
 	if err != nil {
 		return nil, err
@@ -129,3 +143,83 @@ func (r *WorkingTimeRepository) UpdateEmailStatus(ctx context.Context, id int64,
 	_, err := r.DB.ExecContext(ctx, query, status, retryCount, id)
 	return err
 }
+
+// ListWorkingTimes returns an employee's working times that started in [from, to), oldest first.
+// Voided shifts are left out.
+func (r *WorkingTimeRepository) ListWorkingTimes(ctx context.Context, employeeID string, from, to time.Time) ([]model.WorkingTime, error) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("app.employeeId", employeeID))
+
+	query := `SELECT id, employee_id, clock_in_time, clock_out_time, COALESCE(hours_worked, 0),
+                     labor_status, labor_retry_count, email_status, email_retry_count, flagged_absence_id, version
+              FROM working_times
+              WHERE employee_id = $1 AND clock_in_time >= $2 AND clock_in_time < $3 AND voided_at IS NULL
+              ORDER BY clock_in_time`
+
+	rows, err := r.DB.QueryContext(ctx, query, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.WorkingTime
+	for rows.Next() {
+		var wt model.WorkingTime
+		err := rows.Scan(&wt.ID, &wt.EmployeeID, &wt.ClockInTime, &wt.ClockOutTime, &wt.HoursWorked,
+			&wt.LaborStatus, &wt.LaborRetryCount, &wt.EmailStatus, &wt.EmailRetryCount, &wt.FlaggedAbsenceID, &wt.Version)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, wt)
+	}
+
+	return result, rows.Err()
+}
+
+// FlagWorkingTime marks a shift as tapped during an approved absence.
+func (r *WorkingTimeRepository) FlagWorkingTime(ctx context.Context, id int64, absenceID int64) error {
+	query := `UPDATE working_times SET flagged_absence_id = $1 WHERE id = $2`
+	_, err := r.DB.ExecContext(ctx, query, absenceID, id)
+	return err
+}
+
+// VoidWorkingTime marks a shift as void and bumps its version. It reports false if
+// the shift was already voided.
+func (r *WorkingTimeRepository) VoidWorkingTime(ctx context.Context, id int64, voidedBy, reason string, voidedAt time.Time) (bool, error) {
+	query := `UPDATE working_times
+              SET voided_at = $1,
+                  voided_by = $2,
+                  void_reason = $3,
+                  version = version + 1
+              WHERE id = $4 AND voided_at IS NULL`
+
+	res, err := r.DB.ExecContext(ctx, query, voidedAt, voidedBy, reason, id)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// FindLastCheckOut returns the employee's most recent finished shift that clocked out before the given time.
+func (r *WorkingTimeRepository) FindLastCheckOut(ctx context.Context, employeeID string, before time.Time) (*model.WorkingTime, error) {
+	query := `SELECT id, clock_in_time, clock_out_time, COALESCE(hours_worked, 0)
+              FROM working_times
+              WHERE employee_id = $1 AND clock_out_time IS NOT NULL AND clock_out_time <= $2 AND voided_at IS NULL
+              ORDER BY clock_out_time DESC
+              LIMIT 1`
+
+	wt := &model.WorkingTime{EmployeeID: employeeID}
+	err := r.DB.QueryRowContext(ctx, query, employeeID, before).Scan(&wt.ID, &wt.ClockInTime, &wt.ClockOutTime, &wt.HoursWorked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return wt, nil
+}
@@ -0,0 +1,126 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+// QuarantineRepository is the PostgreSQL implementation of repository.QuarantineRepository.
+type QuarantineRepository struct {
+	DB *sql.DB
+}
+
+// NewQuarantineRepository create new instance
+func NewQuarantineRepository(db *sql.DB) repository.QuarantineRepository {
+	return &QuarantineRepository{DB: db}
+}
+
+// Quarantine stores a message that a processor rejected. A message that is quarantined
+// again, because removing it from its queue failed the first time, replaces its row
+// and is QUARANTINED again. The whole row is replaced, so a broker that reuses
+// message IDs cannot leave the row showing another message's body or status.
+func (r *QuarantineRepository) Quarantine(ctx context.Context, m *model.QuarantinedMessage) (int64, error) {
+	attributes, err := json.Marshal(m.Attributes)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := `INSERT INTO quarantined_messages (queue, processor, message_id, employee_id, body, attributes, error, receive_count)
+              VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8)
+              ON CONFLICT (queue, message_id) DO UPDATE
+              SET processor = EXCLUDED.processor,
+                  employee_id = EXCLUDED.employee_id,
+                  body = EXCLUDED.body,
+                  attributes = EXCLUDED.attributes,
+                  error = EXCLUDED.error,
+                  receive_count = EXCLUDED.receive_count,
+                  status = 'QUARANTINED',
+                  resolved_at = NULL,
+                  quarantined_at = CURRENT_TIMESTAMP
+              RETURNING id`
+
+	err = r.DB.QueryRowContext(ctx, query, m.Queue, m.Processor, m.MessageID, m.EmployeeID, m.Body, attributes,
+		m.Error, m.ReceiveCount).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetQuarantined fetches a quarantined message by its ID.
+func (r *QuarantineRepository) GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedMessage, error) {
+	query := `SELECT id, queue, processor, message_id, COALESCE(employee_id, ''), body, attributes, error,
+                     receive_count, status, quarantined_at, resolved_at
+              FROM quarantined_messages WHERE id = $1`
+
+	return scanQuarantined(r.DB.QueryRowContext(ctx, query, id))
+}
+
+// ListQuarantined returns the latest quarantined messages, newest first. An empty
+// queue or status matches every queue or status.
+func (r *QuarantineRepository) ListQuarantined(ctx context.Context, queue string, status model.QuarantineStatus, limit int) ([]model.QuarantinedMessage, error) {
+	query := `SELECT id, queue, processor, message_id, COALESCE(employee_id, ''), body, attributes, error,
+                     receive_count, status, quarantined_at, resolved_at
+              FROM quarantined_messages
+              WHERE ($1 = '' OR queue = $1) AND ($2 = '' OR status = $2)
+              ORDER BY quarantined_at DESC, id DESC
+              LIMIT $3`
+
+	rows, err := r.DB.QueryContext(ctx, query, queue, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.QuarantinedMessage
+	for rows.Next() {
+		m, err := scanQuarantined(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *m)
+	}
+
+	return result, rows.Err()
+}
+
+// SetQuarantineStatus moves a message from status from to status to. Leaving
+// QUARANTINED sets the resolution time; returning to it clears it. It reports false
+// if the message does not exist or is not in status from.
+func (r *QuarantineRepository) SetQuarantineStatus(ctx context.Context, id int64, from, to model.QuarantineStatus) (bool, error) {
+	query := `UPDATE quarantined_messages
+              SET status = $3,
+                  resolved_at = CASE WHEN $3 = 'QUARANTINED' THEN NULL ELSE CURRENT_TIMESTAMP END
+              WHERE id = $1 AND status = $2`
+
+	res, err := r.DB.ExecContext(ctx, query, id, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func scanQuarantined(row rowScanner) (*model.QuarantinedMessage, error) {
+	var m model.QuarantinedMessage
+	var attributes []byte
+	err := row.Scan(&m.ID, &m.Queue, &m.Processor, &m.MessageID, &m.EmployeeID, &m.Body, &attributes, &m.Error,
+		&m.ReceiveCount, &m.Status, &m.QuarantinedAt, &m.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attributes, &m.Attributes); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
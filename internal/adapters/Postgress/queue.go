@@ -0,0 +1,210 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/ports"
+	"checkin.service/pkg/telemetry"
+)
+
+// QueueSender implements ports.MessageSender on the queue_jobs table.
+// The destination is the queue name.
+type QueueSender struct {
+	DB *sql.DB
+}
+
+func NewQueueSender(db *sql.DB) *QueueSender {
+	return &QueueSender{DB: db}
+}
+
+func (s *QueueSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	// Inject trace context into the job attributes
+	attributes := telemetry.InjectTraceAttributes(ctx)
+	for k, v := range ports.ApplySendOptions(opts).Attributes {
+		attributes[k] = v
+	}
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO queue_jobs (queue, body, attributes) VALUES ($1, $2, $3)`
+	_, err = s.DB.ExecContext(ctx, query, destination, string(body), b)
+	return err
+}
+
+// QueueSource implements ports.MessageSource on the queue_jobs table. Receiving a job
+// hides it for VisibilityTimeout and gives it a fresh lease token, which later calls
+// must present, so a worker whose delivery expired cannot settle the redelivery.
+// Jobs that were received MaxReceiveCount times without being settled are moved to
+// queue_dead_letters instead of being delivered again.
+type QueueSource struct {
+	DB    *sql.DB
+	queue string
+	// VisibilityTimeout is how long a received job stays hidden unless extended.
+	VisibilityTimeout time.Duration
+	// PollInterval is how long Receive waits before returning when the queue is empty.
+	PollInterval time.Duration
+	// MaxReceiveCount is the number of deliveries after which a visible job is
+	// dead-lettered. 0 disables the limit.
+	MaxReceiveCount int
+}
+
+func NewQueueSource(db *sql.DB, queue string) *QueueSource {
+	return &QueueSource{
+		DB:                db,
+		queue:             queue,
+		VisibilityTimeout: 30 * time.Second,
+		PollInterval:      time.Second,
+		MaxReceiveCount:   5,
+	}
+}
+
+// System names the broker for traces.
+func (s *QueueSource) System() string {
+	return "postgresql"
+}
+
+func (s *QueueSource) Receive(ctx context.Context, max int) ([]ports.Message, error) {
+	if s.MaxReceiveCount > 0 {
+		if err := s.deadLetterExhausted(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `UPDATE queue_jobs
+              SET visible_at = NOW() + $3 * INTERVAL '1 millisecond',
+                  receive_count = receive_count + 1,
+                  lease_token = gen_random_uuid()::text
+              WHERE id IN (
+                  SELECT id FROM queue_jobs
+                  WHERE queue = $1 AND visible_at <= NOW()
+                    AND ($4 = 0 OR receive_count < $4)
+                  ORDER BY id
+                  LIMIT $2
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING id, body, attributes, lease_token, receive_count`
+
+	rows, err := s.DB.QueryContext(ctx, query, s.queue, max, s.VisibilityTimeout.Milliseconds(), s.MaxReceiveCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []ports.Message
+	for rows.Next() {
+		var (
+			id         int64
+			body       string
+			attributes []byte
+			msg        ports.Message
+		)
+		if err := rows.Scan(&id, &body, &attributes, &msg.Handle, &msg.ReceiveCount); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(attributes, &msg.Attributes); err != nil {
+			return nil, err
+		}
+		msg.ID = strconv.FormatInt(id, 10)
+		msg.Body = []byte(body)
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		// Nothing visible: wait a little, like an SQS long poll, so the worker does not spin.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.PollInterval):
+		}
+	}
+	return messages, nil
+}
+
+// deadLetterExhausted moves the visible jobs of the queue that were already received
+// MaxReceiveCount times to queue_dead_letters.
+func (s *QueueSource) deadLetterExhausted(ctx context.Context) error {
+	query := `WITH moved AS (
+                  DELETE FROM queue_jobs WHERE id IN (
+                      SELECT id FROM queue_jobs
+                      WHERE queue = $1 AND visible_at <= NOW() AND receive_count >= $2
+                      FOR UPDATE SKIP LOCKED
+                  )
+                  RETURNING id, queue, body, attributes, receive_count
+              )
+              INSERT INTO queue_dead_letters (job_id, queue, body, attributes, receive_count, reason)
+              SELECT id, queue, body, attributes, receive_count, 'exceeded the maximum receive count' FROM moved`
+	_, err := s.DB.ExecContext(ctx, query, s.queue, s.MaxReceiveCount)
+	return err
+}
+
+func (s *QueueSource) Ack(ctx context.Context, msg ports.Message) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM queue_jobs WHERE id = $1 AND lease_token = $2`
+	_, err = s.DB.ExecContext(ctx, query, id, msg.Handle)
+	return err
+}
+
+func (s *QueueSource) Nack(ctx context.Context, msg ports.Message, delay time.Duration) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE queue_jobs
+              SET visible_at = NOW() + $3 * INTERVAL '1 millisecond',
+                  lease_token = NULL
+              WHERE id = $1 AND lease_token = $2`
+	_, err = s.DB.ExecContext(ctx, query, id, msg.Handle, delay.Milliseconds())
+	return err
+}
+
+func (s *QueueSource) Extend(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE queue_jobs
+              SET visible_at = NOW() + $3 * INTERVAL '1 millisecond'
+              WHERE id = $1 AND lease_token = $2`
+	_, err = s.DB.ExecContext(ctx, query, id, msg.Handle, timeout.Milliseconds())
+	return err
+}
+
+// ApproximateDepth counts the jobs of the queue that are visible now.
+func (s *QueueSource) ApproximateDepth(ctx context.Context) (int, error) {
+	var depth int
+	query := `SELECT COUNT(*) FROM queue_jobs WHERE queue = $1 AND visible_at <= NOW()`
+	err := s.DB.QueryRowContext(ctx, query, s.queue).Scan(&depth)
+	return depth, err
+}
+
+// DeadLetter moves the job to queue_dead_letters in a single statement.
+func (s *QueueSource) DeadLetter(ctx context.Context, msg ports.Message, reason string) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	query := `WITH moved AS (
+                  DELETE FROM queue_jobs WHERE id = $1 AND lease_token = $2
+                  RETURNING id, queue, body, attributes, receive_count
+              )
+              INSERT INTO queue_dead_letters (job_id, queue, body, attributes, receive_count, reason)
+              SELECT id, queue, body, attributes, receive_count, $3 FROM moved`
+	_, err = s.DB.ExecContext(ctx, query, id, msg.Handle, reason)
+	return err
+}
@@ -0,0 +1,141 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+// ReconciliationRepository is the PostgreSQL implementation of repository.ReconciliationRepository.
+type ReconciliationRepository struct {
+	DB *sql.DB
+}
+
+// NewReconciliationRepository create new instance
+func NewReconciliationRepository(db *sql.DB) repository.ReconciliationRepository {
+	return &ReconciliationRepository{DB: db}
+}
+
+// FindDiscrepancies returns up to limit working times of each kind that need
+// attention, oldest check-out first, and how many there are of each kind in total:
+// channels still PENDING, or PROCESSING under an expired lease, sla after check-out,
+// records with one channel COMPLETED and the other FAILED, and labor channels PARKED
+// in the retry schedule. Voided shifts are never sent, so they are left out. With
+// holdLabor, labor channels whose timesheet is not approved yet are held back, not
+// stuck.
+func (r *ReconciliationRepository) FindDiscrepancies(ctx context.Context, sla time.Duration, holdLabor bool, limit int) ([]model.Discrepancy, map[model.DiscrepancyKind]int, error) {
+	query := `SELECT kind, id, employee_id, labor_status, email_status, clock_out_time, republished_at, total
+              FROM (
+                  SELECT d.*,
+                         ROW_NUMBER() OVER (PARTITION BY kind ORDER BY clock_out_time, id) AS n,
+                         COUNT(*) OVER (PARTITION BY kind) AS total
+                  FROM (
+                      SELECT 'LABOR_STUCK' AS kind, w.id, w.employee_id, w.labor_status, w.email_status, w.clock_out_time,
+                             w.labor_republished_at AS republished_at
+                      FROM working_times w
+                      WHERE w.voided_at IS NULL AND w.clock_out_time < NOW() - $1 * INTERVAL '1 millisecond'
+                        AND (w.labor_status = 'PENDING' OR (w.labor_status = 'PROCESSING' AND w.labor_lease_expires_at < NOW()))
+                        AND NOT ($2 AND NOT EXISTS (
+                            SELECT 1 FROM timesheets t
+                            WHERE t.employee_id = w.employee_id AND t.status = 'APPROVED'
+                              AND w.clock_in_time >= t.period_start AND w.clock_in_time < t.period_end
+                        ))
+                      UNION ALL
+                      SELECT 'EMAIL_STUCK', id, employee_id, labor_status, email_status, clock_out_time,
+                             email_republished_at
+                      FROM working_times
+                      WHERE voided_at IS NULL AND clock_out_time < NOW() - $1 * INTERVAL '1 millisecond'
+                        AND (email_status = 'PENDING' OR (email_status = 'PROCESSING' AND email_lease_expires_at < NOW()))
+                      UNION ALL
+                      SELECT 'LABOR_FAILED', id, employee_id, labor_status, email_status, clock_out_time, NULL
+                      FROM working_times
+                      WHERE voided_at IS NULL AND labor_status = 'FAILED' AND email_status = 'COMPLETED'
+                      UNION ALL
+                      SELECT 'EMAIL_FAILED', id, employee_id, labor_status, email_status, clock_out_time, NULL
+                      FROM working_times
+                      WHERE voided_at IS NULL AND email_status = 'FAILED' AND labor_status = 'COMPLETED'
+                      UNION ALL
+                      SELECT 'LABOR_PARKED', id, employee_id, labor_status, email_status, clock_out_time, NULL
+                      FROM working_times
+                      WHERE voided_at IS NULL AND labor_status = 'PARKED'
+                  ) d
+              ) ranked
+              WHERE n <= $3
+              ORDER BY clock_out_time, id`
+
+	rows, err := r.DB.QueryContext(ctx, query, sla.Milliseconds(), holdLabor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Discrepancy
+	totals := make(map[model.DiscrepancyKind]int)
+	for rows.Next() {
+		var d model.Discrepancy
+		var clockOut sql.NullTime
+		var total int
+		err := rows.Scan(&d.Kind, &d.WorkingTimeID, &d.EmployeeID, &d.LaborStatus, &d.EmailStatus, &clockOut, &d.LastRepublishedAt, &total)
+		if err != nil {
+			return nil, nil, err
+		}
+		d.ClockOutTime = clockOut.Time
+		totals[d.Kind] = total
+		result = append(result, d)
+	}
+
+	return result, totals, rows.Err()
+}
+
+// MarkLaborRepublished records that the labor event of a stuck record is republished
+// now. It reports false if the record is no longer stuck, e.g. a worker leased it since
+// it was found, or was republished less than sla ago, e.g. by another instance.
+func (r *ReconciliationRepository) MarkLaborRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error) {
+	query := `UPDATE working_times
+              SET labor_republished_at = NOW()
+              WHERE id = $1
+                AND (labor_status = 'PENDING' OR (labor_status = 'PROCESSING' AND labor_lease_expires_at < NOW()))
+                AND (labor_republished_at IS NULL OR labor_republished_at < NOW() - $2 * INTERVAL '1 millisecond')`
+
+	return r.mark(ctx, query, id, sla)
+}
+
+// MarkEmailRepublished is MarkLaborRepublished for the email channel.
+func (r *ReconciliationRepository) MarkEmailRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error) {
+	query := `UPDATE working_times
+              SET email_republished_at = NOW()
+              WHERE id = $1
+                AND (email_status = 'PENDING' OR (email_status = 'PROCESSING' AND email_lease_expires_at < NOW()))
+                AND (email_republished_at IS NULL OR email_republished_at < NOW() - $2 * INTERVAL '1 millisecond')`
+
+	return r.mark(ctx, query, id, sla)
+}
+
+// UnmarkLaborRepublished sets the republish mark of a record back to previous after
+// republishing its labor event failed, so the next run tries again.
+func (r *ReconciliationRepository) UnmarkLaborRepublished(ctx context.Context, id int64, previous *time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE working_times SET labor_republished_at = $2 WHERE id = $1`, id, previous)
+	return err
+}
+
+// UnmarkEmailRepublished is UnmarkLaborRepublished for the email channel.
+func (r *ReconciliationRepository) UnmarkEmailRepublished(ctx context.Context, id int64, previous *time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE working_times SET email_republished_at = $2 WHERE id = $1`, id, previous)
+	return err
+}
+
+func (r *ReconciliationRepository) mark(ctx context.Context, query string, id int64, sla time.Duration) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, query, id, sla.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,164 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+)
+
+// createCheckOut inserts a finished shift with the given channel statuses and removes
+// it after the test.
+func createCheckOut(t *testing.T, db *sql.DB, employeeID string, clockIn time.Time, labor, email model.WorkingTimeStatus, voided bool) int64 {
+	t.Helper()
+	var voidedAt *time.Time
+	if voided {
+		voidedAt = &clockIn
+	}
+	var id int64
+	err := db.QueryRow(`INSERT INTO working_times (employee_id, clock_in_time, clock_out_time, labor_status, email_status, voided_at)
+                        VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		employeeID, clockIn, clockIn.Add(8*time.Hour), labor, email, voidedAt).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert working time: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM working_times WHERE id = $1`, id)
+	})
+	return id
+}
+
+func TestFindDiscrepancies(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewReconciliationRepository(db)
+	ctx := context.Background()
+	// Records of their own employees, checked out long before anything else in the
+	// database, come first in every kind.
+	suffix := time.Now().UnixNano()
+	employee := fmt.Sprintf("recon-%d", suffix)
+	approved := fmt.Sprintf("recon-approved-%d", suffix)
+	day := func(d int) time.Time { return time.Date(1990, time.January, d, 9, 0, 0, 0, time.UTC) }
+
+	// Totals include other data in the database, so they are compared to a run
+	// before the records are added.
+	_, before, err := repo.FindDiscrepancies(ctx, time.Hour, false, 1)
+	if err != nil {
+		t.Fatalf("FindDiscrepancies() error = %v", err)
+	}
+
+	laborStuck := createCheckOut(t, db, employee, day(2), model.StatusWorkingPending, model.StatusWorkingCompleted, false)
+	emailStuck := createCheckOut(t, db, employee, day(3), model.StatusWorkingCompleted, model.StatusWorkingPending, false)
+	failed1 := createCheckOut(t, db, employee, day(4), model.StatusWorkingFailed, model.StatusWorkingCompleted, false)
+	failed2 := createCheckOut(t, db, employee, day(5), model.StatusWorkingFailed, model.StatusWorkingCompleted, false)
+	createCheckOut(t, db, employee, day(6), model.StatusWorkingFailed, model.StatusWorkingCompleted, false)
+	emailFailed := createCheckOut(t, db, employee, day(7), model.StatusWorkingCompleted, model.StatusWorkingFailed, false)
+	parked := createCheckOut(t, db, employee, day(8), model.StatusWorkingParked, model.StatusWorkingCompleted, false)
+	createCheckOut(t, db, employee, day(9), model.StatusWorkingPending, model.StatusWorkingPending, true)
+	createCheckOut(t, db, employee, day(10), model.StatusWorkingCompleted, model.StatusWorkingCompleted, false)
+	approvedStuck := createCheckOut(t, db, approved, day(2), model.StatusWorkingPending, model.StatusWorkingCompleted, false)
+
+	_, err = db.Exec(`INSERT INTO timesheets (employee_id, period_start, period_end, status) VALUES ($1, $2, $3, $4)`,
+		approved, day(1), day(15), model.StatusTimesheetApproved)
+	if err != nil {
+		t.Fatalf("insert timesheet: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM timesheets WHERE employee_id = $1`, approved)
+	})
+
+	find := func(holdLabor bool) ([]int64, map[model.DiscrepancyKind]int) {
+		t.Helper()
+		found, totals, err := repo.FindDiscrepancies(ctx, time.Hour, holdLabor, 2)
+		if err != nil {
+			t.Fatalf("FindDiscrepancies() error = %v", err)
+		}
+		var ids []int64
+		for _, d := range found {
+			if d.EmployeeID == employee || d.EmployeeID == approved {
+				ids = append(ids, d.WorkingTimeID)
+			}
+		}
+		slices.Sort(ids)
+		return ids, totals
+	}
+
+	t.Run("kinds, limit per kind and totals", func(t *testing.T) {
+		ids, totals := find(false)
+		// The third LABOR_FAILED record is beyond the limit of 2; voided and
+		// completed records are no discrepancies.
+		want := []int64{laborStuck, emailStuck, failed1, failed2, emailFailed, parked, approvedStuck}
+		slices.Sort(want)
+		if !slices.Equal(ids, want) {
+			t.Errorf("found working times %v, want %v", ids, want)
+		}
+
+		added := map[model.DiscrepancyKind]int{
+			model.DiscrepancyLaborStuck:  2,
+			model.DiscrepancyEmailStuck:  1,
+			model.DiscrepancyLaborFailed: 3,
+			model.DiscrepancyEmailFailed: 1,
+			model.DiscrepancyLaborParked: 1,
+		}
+		for kind, n := range added {
+			if got := totals[kind] - before[kind]; got != n {
+				t.Errorf("%s total grew by %d, want %d", kind, got, n)
+			}
+		}
+	})
+
+	t.Run("held labor is left out", func(t *testing.T) {
+		ids, _ := find(true)
+		if slices.Contains(ids, laborStuck) {
+			t.Error("found a labor channel held for its timesheet")
+		}
+		if !slices.Contains(ids, approvedStuck) {
+			t.Error("did not find a labor channel of an approved timesheet")
+		}
+		if !slices.Contains(ids, emailStuck) {
+			t.Error("did not find a stuck email channel of an unapproved timesheet")
+		}
+	})
+}
+
+func TestMarkRepublished(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewReconciliationRepository(db)
+	ctx := context.Background()
+	employee := fmt.Sprintf("recon-%d", time.Now().UnixNano())
+	clockIn := time.Now().Add(-24 * time.Hour)
+
+	pending := createCheckOut(t, db, employee, clockIn, model.StatusWorkingPending, model.StatusWorkingCompleted, false)
+	leased := createCheckOut(t, db, employee, clockIn, model.StatusWorkingProcessing, model.StatusWorkingCompleted, false)
+	expired := createCheckOut(t, db, employee, clockIn, model.StatusWorkingProcessing, model.StatusWorkingCompleted, false)
+	lease := func(id int64, expiresIn time.Duration) {
+		if _, err := db.Exec(`UPDATE working_times SET labor_lease_expires_at = NOW() + $2 * INTERVAL '1 millisecond' WHERE id = $1`, id, expiresIn.Milliseconds()); err != nil {
+			t.Fatalf("set lease: %v", err)
+		}
+	}
+	lease(leased, time.Minute)
+	lease(expired, -time.Minute)
+
+	tests := []struct {
+		name string
+		id   int64
+		want bool
+	}{
+		{name: "pending", id: pending, want: true},
+		{name: "marked within the SLA", id: pending, want: false},
+		{name: "live lease", id: leased, want: false},
+		{name: "expired lease", id: expired, want: true},
+	}
+	for _, tt := range tests {
+		got, err := repo.MarkLaborRepublished(ctx, tt.id, time.Hour)
+		if err != nil {
+			t.Fatalf("%s: MarkLaborRepublished() error = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: MarkLaborRepublished() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+// RetryScheduleRepository is the PostgreSQL implementation of repository.RetryScheduleRepository.
+type RetryScheduleRepository struct {
+	DB *sql.DB
+}
+
+// NewRetryScheduleRepository create new instance
+func NewRetryScheduleRepository(db *sql.DB) repository.RetryScheduleRepository {
+	return &RetryScheduleRepository{DB: db}
+}
+
+// Park stores a job in the schedule. A record has at most one parked job per queue;
+// parking it again replaces the message and the next attempt but keeps its place in
+// the employee's order. Its attempts never go down, e.g. when a duplicate that was
+// never parked is parked behind the employee's earlier records.
+func (r *RetryScheduleRepository) Park(ctx context.Context, job *model.ScheduledRetry) error {
+	attributes, err := json.Marshal(job.Attributes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO retry_schedule (queue, working_time_id, employee_id, body, attributes, attempts, next_attempt_at, last_error)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              ON CONFLICT (queue, working_time_id) DO UPDATE
+              SET body = EXCLUDED.body,
+                  attributes = EXCLUDED.attributes,
+                  attempts = GREATEST(retry_schedule.attempts, EXCLUDED.attempts),
+                  next_attempt_at = EXCLUDED.next_attempt_at,
+                  last_error = EXCLUDED.last_error,
+                  claimed_until = NULL`
+
+	_, err = r.DB.ExecContext(ctx, query, job.Queue, job.WorkingTimeID, job.EmployeeID, string(job.Body), attributes,
+		job.Attempts, job.NextAttemptAt, job.LastError)
+	return err
+}
+
+// HasParked reports whether the employee has jobs waiting in the schedule of queue.
+// Jobs the scheduler is sending right now do not count.
+func (r *RetryScheduleRepository) HasParked(ctx context.Context, queue, employeeID string) (bool, error) {
+	query := `SELECT EXISTS (
+                  SELECT 1 FROM retry_schedule
+                  WHERE queue = $1 AND employee_id = $2 AND (claimed_until IS NULL OR claimed_until < NOW())
+              )`
+
+	var parked bool
+	err := r.DB.QueryRowContext(ctx, query, queue, employeeID).Scan(&parked)
+	return parked, err
+}
+
+// ClaimDue takes all jobs of up to employees employees whose oldest job is due, and
+// marks them as being sent for lease. Jobs are returned per employee in parking
+// order. A scheduler that dies mid-send leaves them due again once the lease ends.
+func (r *RetryScheduleRepository) ClaimDue(ctx context.Context, queue string, employees int, lease time.Duration) ([]model.ScheduledRetry, error) {
+	query := `WITH due AS (
+                  SELECT employee_id
+                  FROM retry_schedule
+                  WHERE queue = $1 AND (claimed_until IS NULL OR claimed_until < NOW())
+                  GROUP BY employee_id
+                  HAVING (ARRAY_AGG(next_attempt_at ORDER BY parked_at, id))[1] <= NOW()
+                  ORDER BY MIN(parked_at)
+                  LIMIT $2
+              ), claimed AS (
+                  UPDATE retry_schedule s
+                  SET claimed_until = NOW() + $3 * INTERVAL '1 millisecond'
+                  FROM due
+                  WHERE s.queue = $1 AND s.employee_id = due.employee_id
+                    AND (s.claimed_until IS NULL OR s.claimed_until < NOW())
+                  RETURNING s.id, s.queue, s.working_time_id, s.employee_id, s.body, s.attributes, s.attempts,
+                            s.next_attempt_at, s.parked_at, COALESCE(s.last_error, '')
+              )
+              SELECT * FROM claimed ORDER BY employee_id, parked_at, id`
+
+	rows, err := r.DB.QueryContext(ctx, query, queue, employees, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.ScheduledRetry
+	for rows.Next() {
+		var job model.ScheduledRetry
+		var body string
+		var attributes []byte
+		err := rows.Scan(&job.ID, &job.Queue, &job.WorkingTimeID, &job.EmployeeID, &body, &attributes, &job.Attempts,
+			&job.NextAttemptAt, &job.ParkedAt, &job.LastError)
+		if err != nil {
+			return nil, err
+		}
+		job.Body = []byte(body)
+		if err := json.Unmarshal(attributes, &job.Attributes); err != nil {
+			return nil, err
+		}
+		result = append(result, job)
+	}
+
+	return result, rows.Err()
+}
+
+// Remove deletes a claimed job after it was sent to its queue. A job that was parked
+// again in the meantime is kept.
+func (r *RetryScheduleRepository) Remove(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM retry_schedule WHERE id = $1 AND claimed_until IS NOT NULL`, id)
+	return err
+}
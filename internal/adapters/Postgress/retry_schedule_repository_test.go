@@ -0,0 +1,149 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// newTestDB connects to the database in POSTGRES_TEST_DSN, which must have init.sql
+// applied. The test is skipped when the variable is not set.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// createWorkingTime inserts a record to park jobs for and removes it after the test.
+func createWorkingTime(t *testing.T, db *sql.DB, employeeID string) int64 {
+	t.Helper()
+	var id int64
+	err := db.QueryRow(`INSERT INTO working_times (employee_id, clock_in_time) VALUES ($1, NOW()) RETURNING id`, employeeID).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert working time: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM retry_schedule WHERE working_time_id = $1`, id)
+		_, _ = db.Exec(`DELETE FROM working_times WHERE id = $1`, id)
+	})
+	return id
+}
+
+func TestRetryScheduleClaimDue(t *testing.T) {
+	db := newTestDB(t)
+	repo := &RetryScheduleRepository{DB: db}
+	ctx := context.Background()
+	// A queue of its own keeps other data in the database out of the claims.
+	queue := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	now := time.Now()
+
+	park := func(employeeID string, next time.Time) int64 {
+		id := createWorkingTime(t, db, employeeID)
+		err := repo.Park(ctx, &model.ScheduledRetry{
+			Queue: queue, WorkingTimeID: id, EmployeeID: employeeID,
+			Body: []byte(fmt.Sprintf(`{"workingTimeId":%d}`, id)), Attributes: map[string]string{},
+			Attempts: 1, NextAttemptAt: next, LastError: "unavailable",
+		})
+		if err != nil {
+			t.Fatalf("Park() error = %v", err)
+		}
+		return id
+	}
+
+	// emp-a's oldest job is due, so both of its jobs are claimed in parking order.
+	a1 := park("emp-a", now.Add(-time.Minute))
+	a2 := park("emp-a", now.Add(time.Hour))
+	// emp-b's oldest job is not due yet, which holds back its due later job.
+	park("emp-b", now.Add(time.Hour))
+	park("emp-b", now.Add(-time.Minute))
+
+	jobs, err := repo.ClaimDue(ctx, queue, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDue() error = %v", err)
+	}
+	var claimed []int64
+	for _, job := range jobs {
+		claimed = append(claimed, job.WorkingTimeID)
+	}
+	if want := []int64{a1, a2}; !slices.Equal(claimed, want) {
+		t.Fatalf("claimed working times %v, want %v", claimed, want)
+	}
+
+	// Claimed jobs are hidden while their lease lasts.
+	again, err := repo.ClaimDue(ctx, queue, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDue() error = %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("claimed %d jobs again during the lease, want 0", len(again))
+	}
+	parked, err := repo.HasParked(ctx, queue, "emp-a")
+	if err != nil {
+		t.Fatalf("HasParked() error = %v", err)
+	}
+	if parked {
+		t.Error("HasParked() counts jobs that are being sent")
+	}
+
+	if err := repo.Remove(ctx, jobs[0].ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	var left int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM retry_schedule WHERE queue = $1`, queue).Scan(&left); err != nil {
+		t.Fatalf("count jobs: %v", err)
+	}
+	if left != 3 {
+		t.Errorf("%d jobs left after Remove(), want 3", left)
+	}
+}
+
+func TestRetrySchedulePark(t *testing.T) {
+	db := newTestDB(t)
+	repo := &RetryScheduleRepository{DB: db}
+	ctx := context.Background()
+	queue := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	id := createWorkingTime(t, db, "emp-a")
+
+	park := func(attempts int) {
+		err := repo.Park(ctx, &model.ScheduledRetry{
+			Queue: queue, WorkingTimeID: id, EmployeeID: "emp-a", Body: []byte("{}"),
+			Attributes: map[string]string{}, Attempts: attempts, NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Park() error = %v", err)
+		}
+	}
+	attempts := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT attempts FROM retry_schedule WHERE queue = $1 AND working_time_id = $2`, queue, id).Scan(&n); err != nil {
+			t.Fatalf("read attempts: %v", err)
+		}
+		return n
+	}
+
+	park(3)
+	// A duplicate that was never parked is parked behind with 0 attempts.
+	park(0)
+	if got := attempts(); got != 3 {
+		t.Errorf("attempts after parking a duplicate = %d, want 3", got)
+	}
+	park(4)
+	if got := attempts(); got != 4 {
+		t.Errorf("attempts after parking again = %d, want 4", got)
+	}
+}
@@ -0,0 +1,129 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+const timesheetColumns = `id, employee_id, period_start, period_end, status, total_hours,
+                          submitted_at, COALESCE(reviewed_by, ''), reviewed_at, COALESCE(rejection_reason, '')`
+
+// TimesheetRepository is the PostgreSQL implementation of repository.TimesheetRepository.
+type TimesheetRepository struct {
+	DB *sql.DB
+}
+
+// NewTimesheetRepository create new instance
+func NewTimesheetRepository(db *sql.DB) repository.TimesheetRepository {
+	return &TimesheetRepository{DB: db}
+}
+
+// GetOrCreateTimesheet returns the employee's timesheet for a pay period, opening it if needed.
+func (r *TimesheetRepository) GetOrCreateTimesheet(ctx context.Context, employeeID string, periodStart, periodEnd time.Time) (*model.Timesheet, error) {
+	query := `INSERT INTO timesheets (employee_id, period_start, period_end, status)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (employee_id, period_start) DO UPDATE SET employee_id = EXCLUDED.employee_id
+              RETURNING ` + timesheetColumns
+
+	return scanTimesheet(r.DB.QueryRowContext(ctx, query, employeeID, periodStart, periodEnd, model.StatusTimesheetOpen))
+}
+
+// FindTimesheet returns the employee's timesheet for a pay period, or nil if none was opened.
+func (r *TimesheetRepository) FindTimesheet(ctx context.Context, employeeID string, periodStart time.Time) (*model.Timesheet, error) {
+	query := `SELECT ` + timesheetColumns + ` FROM timesheets WHERE employee_id = $1 AND period_start = $2`
+
+	ts, err := scanTimesheet(r.DB.QueryRowContext(ctx, query, employeeID, periodStart))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ts, err
+}
+
+// GetTimesheet fetches a timesheet by its ID.
+func (r *TimesheetRepository) GetTimesheet(ctx context.Context, id int64) (*model.Timesheet, error) {
+	query := `SELECT ` + timesheetColumns + ` FROM timesheets WHERE id = $1`
+
+	return scanTimesheet(r.DB.QueryRowContext(ctx, query, id))
+}
+
+// ListTimesheets returns the timesheets in a given status, oldest period first.
+func (r *TimesheetRepository) ListTimesheets(ctx context.Context, status model.TimesheetStatus) ([]model.Timesheet, error) {
+	query := `SELECT ` + timesheetColumns + ` FROM timesheets WHERE status = $1 ORDER BY period_start, employee_id`
+
+	rows, err := r.DB.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Timesheet
+	for rows.Next() {
+		ts, err := scanTimesheet(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *ts)
+	}
+
+	return result, rows.Err()
+}
+
+// SubmitTimesheet moves an open or rejected timesheet to SUBMITTED.
+// It reports false if the timesheet was not in a submittable state.
+func (r *TimesheetRepository) SubmitTimesheet(ctx context.Context, id int64, totalHours float64, submittedAt time.Time) (bool, error) {
+	query := `UPDATE timesheets
+              SET status = $1,
+                  total_hours = $2,
+                  submitted_at = $3,
+                  reviewed_by = NULL,
+                  reviewed_at = NULL,
+                  rejection_reason = NULL
+              WHERE id = $4 AND status IN ($5, $6)`
+
+	res, err := r.DB.ExecContext(ctx, query, model.StatusTimesheetSubmitted, totalHours, submittedAt, id,
+		model.StatusTimesheetOpen, model.StatusTimesheetRejected)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ReviewTimesheet records a supervisor's approval or rejection of a submitted timesheet.
+// It reports false if the timesheet was not awaiting review.
+func (r *TimesheetRepository) ReviewTimesheet(ctx context.Context, id int64, status model.TimesheetStatus, reviewer, reason string, reviewedAt time.Time) (bool, error) {
+	query := `UPDATE timesheets
+              SET status = $1,
+                  reviewed_by = $2,
+                  reviewed_at = $3,
+                  rejection_reason = NULLIF($4, '')
+              WHERE id = $5 AND status = $6`
+
+	res, err := r.DB.ExecContext(ctx, query, status, reviewer, reviewedAt, reason, id, model.StatusTimesheetSubmitted)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTimesheet(row rowScanner) (*model.Timesheet, error) {
+	ts := &model.Timesheet{}
+	err := row.Scan(&ts.ID, &ts.EmployeeID, &ts.PeriodStart, &ts.PeriodEnd, &ts.Status, &ts.TotalHours,
+		&ts.SubmittedAt, &ts.ReviewedBy, &ts.ReviewedAt, &ts.RejectionReason)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
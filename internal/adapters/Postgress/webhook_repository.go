@@ -0,0 +1,216 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+// WebhookRepository is the PostgreSQL implementation of repository.WebhookRepository.
+type WebhookRepository struct {
+	DB *sql.DB
+}
+
+// NewWebhookRepository create new instance
+func NewWebhookRepository(db *sql.DB) repository.WebhookRepository {
+	return &WebhookRepository{DB: db}
+}
+
+// CreateSubscription stores a new, active subscription.
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) (int64, error) {
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := `INSERT INTO webhook_subscriptions (name, url, event_types, secret)
+              VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err = r.DB.QueryRowContext(ctx, query, sub.Name, sub.URL, eventTypes, sub.Secret).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetSubscription fetches a subscription by its ID.
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	query := `SELECT id, name, url, event_types, secret, active, created_at
+              FROM webhook_subscriptions WHERE id = $1`
+
+	return scanSubscription(r.DB.QueryRowContext(ctx, query, id))
+}
+
+// ListSubscriptions returns all subscriptions, including deactivated ones.
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	query := `SELECT id, name, url, event_types, secret, active, created_at
+              FROM webhook_subscriptions ORDER BY id`
+
+	return r.querySubscriptions(ctx, query)
+}
+
+// DeactivateSubscription stops deliveries to a subscription. Its delivery log is kept.
+// It reports false if there is no active subscription with that ID.
+func (r *WebhookRepository) DeactivateSubscription(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE webhook_subscriptions SET active = FALSE WHERE id = $1 AND active`
+
+	res, err := r.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ListSubscribers returns the active subscriptions to eventType.
+func (r *WebhookRepository) ListSubscribers(ctx context.Context, eventType string) ([]model.WebhookSubscription, error) {
+	query := `SELECT id, name, url, event_types, secret, active, created_at
+              FROM webhook_subscriptions
+              WHERE active AND event_types ? $1
+              ORDER BY id`
+
+	return r.querySubscriptions(ctx, query, eventType)
+}
+
+// EnqueueDelivery creates a pending delivery of an event to a subscription. An event
+// that was already enqueued for the subscription is ignored, so redelivered queue
+// messages do not call the subscriber twice.
+func (r *WebhookRepository) EnqueueDelivery(ctx context.Context, subscriptionID int64, eventID, eventType string, payload []byte) error {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (subscription_id, event_id) DO NOTHING`
+
+	_, err := r.DB.ExecContext(ctx, query, subscriptionID, eventID, eventType, string(payload))
+	return err
+}
+
+// ClaimDueDeliveries takes up to limit due, pending deliveries of active subscriptions
+// and pushes their next attempt back by lease, so other dispatchers skip them while
+// they are sent. A dispatcher that dies mid-send leaves them due again once the lease ends.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	query := `WITH claimed AS (
+                  UPDATE webhook_deliveries
+                  SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+                  WHERE id IN (
+                      SELECT id FROM webhook_deliveries
+                      WHERE status = $3 AND next_attempt_at <= NOW()
+                        AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE active)
+                      ORDER BY next_attempt_at
+                      LIMIT $1
+                      FOR UPDATE SKIP LOCKED
+                  )
+                  RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at
+              )
+              SELECT c.id, c.subscription_id, c.event_id, c.event_type, c.payload, c.status, c.attempts, c.next_attempt_at, c.created_at,
+                     s.url, s.secret
+              FROM claimed c JOIN webhook_subscriptions s ON s.id = c.subscription_id`
+
+	rows, err := r.DB.QueryContext(ctx, query, limit, lease.Milliseconds(), model.StatusDeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var payload string
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt,
+			&d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		result = append(result, d)
+	}
+
+	return result, rows.Err()
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt: status, attempt count,
+// next attempt time, last response and delivery time.
+func (r *WebhookRepository) RecordDeliveryAttempt(ctx context.Context, d *model.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries
+              SET status = $1,
+                  attempts = $2,
+                  next_attempt_at = $3,
+                  last_status_code = NULLIF($4, 0),
+                  last_error = NULLIF($5, ''),
+                  delivered_at = $6
+              WHERE id = $7`
+
+	_, err := r.DB.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt, d.ID)
+	return err
+}
+
+// ListDeliveries returns the latest deliveries of a subscription, newest first. An
+// empty status matches every status.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, status model.WebhookDeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	query := `SELECT id, subscription_id, event_id, event_type, status, attempts, next_attempt_at,
+                     COALESCE(last_status_code, 0), COALESCE(last_error, ''), delivered_at, created_at
+              FROM webhook_deliveries
+              WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+              ORDER BY id DESC
+              LIMIT $3`
+
+	rows, err := r.DB.QueryContext(ctx, query, subscriptionID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, query string, args ...any) ([]model.WebhookSubscription, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *sub)
+	}
+
+	return result, rows.Err()
+}
+
+func scanSubscription(row interface{ Scan(dest ...any) error }) (*model.WebhookSubscription, error) {
+	sub := &model.WebhookSubscription{}
+	var eventTypes []byte
+	err := row.Scan(&sub.ID, &sub.Name, &sub.URL, &eventTypes, &sub.Secret, &sub.Active, &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(eventTypes, &sub.EventTypes); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,58 @@
+package snsadapter
+
+import (
+	"context"
+
+	sqsadapter "checkin.service/internal/adapters/SQS"
+	"checkin.service/internal/ports"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNSSender implements MessageSender for AWS SNS. The destination is a topic ARN;
+// the queues subscribed to the topic pick the events they need with filter
+// policies on the message attributes.
+type SNSSender struct {
+	client SNSClient
+}
+
+// SNSClient defines the interface for the AWS SNS client.
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+func (s *SNSSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	options := ports.ApplySendOptions(opts)
+
+	// The same attributes, trace context included, as a direct SQS send; SNS passes
+	// them on to the subscribed queues inside its notification.
+	attributes := make(map[string]types.MessageAttributeValue)
+	for k, v := range sqsadapter.MessageAttributes(ctx, options.Attributes) {
+		attributes[k] = types.MessageAttributeValue{DataType: v.DataType, StringValue: v.StringValue}
+	}
+
+	input := &sns.PublishInput{
+		TopicArn:          aws.String(destination),
+		Message:           aws.String(string(body)),
+		MessageAttributes: attributes,
+	}
+	if sqsadapter.IsFIFO(destination) {
+		// FIFO topics only deliver to FIFO queues, with the same grouping.
+		input.MessageGroupId, input.MessageDeduplicationId = sqsadapter.FIFOFields(options)
+	}
+
+	_, err := s.client.Publish(ctx, input)
+	return err
+}
+
+// NewSNSProducer creates a new Producer that publishes every event once to topicARN.
+func NewSNSProducer(client SNSClient, topicARN string) *ports.Producer {
+	routes := ports.DefaultRoutes(ports.Destinations{
+		Labor:      topicARN,
+		Email:      topicARN,
+		Compliance: topicARN,
+		Events:     topicARN,
+	})
+	return ports.NewProducer(&SNSSender{client: client}, routes)
+}
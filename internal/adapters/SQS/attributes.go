@@ -0,0 +1,48 @@
+package sqsadapter
+
+import (
+	"context"
+	"strings"
+
+	"checkin.service/internal/ports"
+	"checkin.service/pkg/telemetry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// DefaultMessageGroup is the FIFO message group of messages without an ordering key.
+const DefaultMessageGroup = "default"
+
+// IsFIFO reports whether a queue URL or SNS topic ARN names a FIFO queue or topic.
+func IsFIFO(name string) bool {
+	return strings.HasSuffix(name, ".fifo")
+}
+
+// FIFOFields orders messages per ordering key (the employee ID) and deduplicates them
+// by the event's deduplication ID. Without one the queue must use content-based deduplication.
+// The SNS adapter uses the same fields, so a FIFO topic groups like a FIFO queue.
+func FIFOFields(options ports.SendOptions) (groupID, deduplicationID *string) {
+	group := options.OrderingKey
+	if group == "" {
+		group = DefaultMessageGroup
+	}
+	if options.DeduplicationID != "" {
+		deduplicationID = aws.String(options.DeduplicationID)
+	}
+	return aws.String(group), deduplicationID
+}
+
+// MessageAttributes returns the trace context of ctx and attributes as string message
+// attributes. The SNS adapter publishes the same attributes, so a queue subscribed to
+// the topic receives what a direct send would carry.
+func MessageAttributes(ctx context.Context, attributes map[string]string) map[string]types.MessageAttributeValue {
+	out := telemetry.InjectTraceContext(ctx)
+	for k, v := range attributes {
+		out[k] = stringAttribute(v)
+	}
+	return out
+}
+
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
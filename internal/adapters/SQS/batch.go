@@ -0,0 +1,144 @@
+package sqsadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// maxBatchEntries is the most entries SQS accepts in one batch request.
+	maxBatchEntries = 10
+	// maxBatchBytes is the most payload SQS accepts in one SendMessageBatch request.
+	maxBatchBytes = 256 * 1024
+	// batchTimeout bounds a batch request, which serves several callers at once.
+	batchTimeout = 30 * time.Second
+)
+
+var errMissingBatchResult = errors.New("no result for batch entry")
+
+// batcher coalesces calls made within linger of each other into one batch request
+// of up to maxBatchEntries entries (and maxBatchBytes, for sends). Each caller
+// blocks until its batch is sent and gets the result of its own entry, so a
+// partial failure only fails the entries SQS rejected.
+type batcher[T any] struct {
+	linger time.Duration
+	// send makes the batch request. Entry i has the batch entry ID strconv.Itoa(i),
+	// and the returned slice holds the error of each entry.
+	send func(ctx context.Context, entries []T) []error
+
+	mu      sync.Mutex
+	pending *batch[T]
+}
+
+type batch[T any] struct {
+	ctx     context.Context
+	entries []T
+	results []chan error
+	bytes   int
+}
+
+func newBatcher[T any](linger time.Duration, send func(ctx context.Context, entries []T) []error) *batcher[T] {
+	return &batcher[T]{linger: linger, send: send}
+}
+
+// do adds entry, of the given payload size, to the pending batch and waits for its
+// result. If ctx ends first do returns ctx.Err(), but the entry is still sent.
+func (b *batcher[T]) do(ctx context.Context, entry T, size int) error {
+	result := make(chan error, 1)
+
+	b.mu.Lock()
+	if b.pending != nil && b.pending.bytes+size > maxBatchBytes {
+		go b.flush(b.detach())
+	}
+	if b.pending == nil {
+		// The batch is sent on the context of the call that opened it, without its
+		// cancellation, so its trace covers the request.
+		b.pending = &batch[T]{ctx: context.WithoutCancel(ctx)}
+		if b.linger > 0 {
+			pending := b.pending
+			time.AfterFunc(b.linger, func() {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				if b.pending == pending {
+					go b.flush(b.detach())
+				}
+			})
+		}
+	}
+	b.pending.entries = append(b.pending.entries, entry)
+	b.pending.results = append(b.pending.results, result)
+	b.pending.bytes += size
+	if len(b.pending.entries) == maxBatchEntries || b.linger <= 0 {
+		go b.flush(b.detach())
+	}
+	b.mu.Unlock()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// detach takes the pending batch; b.mu must be held.
+func (b *batcher[T]) detach() *batch[T] {
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+func (b *batcher[T]) flush(pending *batch[T]) {
+	ctx, cancel := context.WithTimeout(pending.ctx, batchTimeout)
+	defer cancel()
+
+	errs := b.send(ctx, pending.entries)
+	for i, result := range pending.results {
+		result <- errs[i]
+	}
+}
+
+// batchResults maps the failed entries of a batch response to per-entry errors.
+// Entries that are neither successful nor failed get errMissingBatchResult.
+func batchResults(n int, successful []string, failed map[string]error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = errMissingBatchResult
+	}
+	for _, id := range successful {
+		if i, err := strconv.Atoi(id); err == nil && i < n {
+			errs[i] = nil
+		}
+	}
+	for id, err := range failed {
+		if i, convErr := strconv.Atoi(id); convErr == nil && i < n {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// repeatErr returns err for each of n entries, for a batch request that failed as a whole.
+func repeatErr(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// failedEntries maps the failed entries of a batch response by entry ID.
+func failedEntries(failed []types.BatchResultErrorEntry) map[string]error {
+	errs := make(map[string]error, len(failed))
+	for _, f := range failed {
+		errs[aws.ToString(f.Id)] = fmt.Errorf("%s: %s", aws.ToString(f.Code), aws.ToString(f.Message))
+	}
+	return errs
+}
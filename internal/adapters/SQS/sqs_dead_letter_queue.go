@@ -0,0 +1,222 @@
+package sqsadapter
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// maxDeadLetterScan is the most messages a List or Redrive looks at.
+	maxDeadLetterScan = 10000
+	// scanVisibilityTimeout hides the messages of a scan from the next receive of the
+	// same scan. Messages that are not moved are made visible again right after.
+	scanVisibilityTimeout = time.Minute
+	// maxVisibilityTimeout is the longest visibility timeout SQS accepts.
+	maxVisibilityTimeout = 12 * time.Hour
+)
+
+// SQSDeadLetterQueue implements ports.DeadLetterQueue on an SQS dead-letter queue. SQS
+// cannot browse a queue, so List and Redrive receive all messages with a short
+// visibility timeout and make the ones they leave in the queue visible again.
+type SQSDeadLetterQueue struct {
+	client    SQSConsumerClient
+	queueURL  string
+	sourceURL string
+}
+
+// NewSQSDeadLetterQueue creates the dead-letter queue at queueURL. Redriven messages go
+// back to the queue recorded when they were dead-lettered, or to sourceURL for
+// messages the redrive policy moved.
+func NewSQSDeadLetterQueue(client SQSConsumerClient, queueURL, sourceURL string) *SQSDeadLetterQueue {
+	return &SQSDeadLetterQueue{client: client, queueURL: queueURL, sourceURL: sourceURL}
+}
+
+func (q *SQSDeadLetterQueue) List(ctx context.Context, max int) ([]ports.DeadLetter, error) {
+	messages, err := q.scan(ctx, max, scanVisibilityTimeout)
+	defer q.release(context.WithoutCancel(ctx), messages)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ports.DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, toDeadLetter(fromSQSMessage(msg)))
+	}
+	return result, nil
+}
+
+func (q *SQSDeadLetterQueue) Redrive(ctx context.Context, ids []string, perSecond int, beforeSend func(id string) error) ([]string, error) {
+	interval := time.Second / time.Duration(max(perSecond, 1))
+
+	// The selected messages must stay hidden until they are all sent.
+	timeout := min(scanVisibilityTimeout+time.Duration(len(ids))*interval, maxVisibilityTimeout)
+	messages, err := q.scan(ctx, maxDeadLetterScan, timeout)
+	if err != nil {
+		q.release(context.WithoutCancel(ctx), messages)
+		return nil, err
+	}
+
+	var selected, rest []types.Message
+	for _, msg := range messages {
+		if slices.Contains(ids, aws.ToString(msg.MessageId)) {
+			selected = append(selected, msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	q.release(ctx, rest)
+
+	var moved []string
+	for i, msg := range selected {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				q.release(context.WithoutCancel(ctx), selected[i:])
+				return moved, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		if beforeSend != nil {
+			if err := beforeSend(aws.ToString(msg.MessageId)); err != nil {
+				q.release(context.WithoutCancel(ctx), selected[i:])
+				return moved, err
+			}
+		}
+		if err := q.redrive(ctx, msg); err != nil {
+			q.release(context.WithoutCancel(ctx), selected[i:])
+			return moved, err
+		}
+		moved = append(moved, aws.ToString(msg.MessageId))
+	}
+	return moved, nil
+}
+
+// redrive sends one message back to its source queue without the dead-letter
+// attributes and deletes it from the dead-letter queue.
+func (q *SQSDeadLetterQueue) redrive(ctx context.Context, msg types.Message) error {
+	m := fromSQSMessage(msg)
+	destination := m.Attributes[sourceQueueAttribute]
+	if destination == "" {
+		destination = q.sourceURL
+	}
+
+	attributes := make(map[string]types.MessageAttributeValue, len(m.Attributes))
+	for k, v := range m.Attributes {
+		if k != failureReasonAttribute && k != failedAtAttribute && k != sourceQueueAttribute {
+			attributes[k] = stringAttribute(v)
+		}
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(destination),
+		MessageBody:       aws.String(string(m.Body)),
+		MessageAttributes: attributes,
+	}
+	if IsFIFO(destination) {
+		input.MessageGroupId, input.MessageDeduplicationId = FIFOFields(ports.SendOptions{OrderingKey: m.GroupID, DeduplicationID: "redrive-" + m.ID})
+	}
+	if _, err := q.client.SendMessage(ctx, input); err != nil {
+		return err
+	}
+
+	output, err := q.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(q.queueURL),
+		Entries:  []types.DeleteMessageBatchRequestEntry{{Id: aws.String("0"), ReceiptHandle: msg.ReceiptHandle}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Failed) > 0 {
+		return errors.New(aws.ToString(output.Failed[0].Message))
+	}
+	return nil
+}
+
+// scan receives up to max messages and hides them for timeout. It returns the
+// messages received so far along with an error.
+func (q *SQSDeadLetterQueue) scan(ctx context.Context, max int, timeout time.Duration) ([]types.Message, error) {
+	seen := make(map[string]types.Message)
+	var order []string
+	for len(order) < max {
+		output, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(q.queueURL),
+			MaxNumberOfMessages:   int32(min(maxReceiveBatch, max-len(order))),
+			VisibilityTimeout:     int32(timeout.Seconds()),
+			WaitTimeSeconds:       1,
+			MessageAttributeNames: []string{"All"},
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameApproximateReceiveCount,
+				types.MessageSystemAttributeNameMessageGroupId,
+			},
+		})
+		if err != nil {
+			return collect(seen, order), err
+		}
+		if len(output.Messages) == 0 {
+			break
+		}
+		for _, msg := range output.Messages {
+			id := aws.ToString(msg.MessageId)
+			if _, ok := seen[id]; !ok {
+				order = append(order, id)
+			}
+			// The latest receipt handle is the valid one.
+			seen[id] = msg
+		}
+	}
+	return collect(seen, order), nil
+}
+
+// release makes messages visible again.
+func (q *SQSDeadLetterQueue) release(ctx context.Context, messages []types.Message) {
+	for chunk := range slices.Chunk(messages, maxBatchEntries) {
+		entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(chunk))
+		for i, msg := range chunk {
+			entries[i] = types.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: 0,
+			}
+		}
+		// A message that cannot be released becomes visible when the scan's timeout ends.
+		_, _ = q.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: aws.String(q.queueURL),
+			Entries:  entries,
+		})
+	}
+}
+
+func collect(seen map[string]types.Message, order []string) []types.Message {
+	messages := make([]types.Message, 0, len(order))
+	for _, id := range order {
+		messages = append(messages, seen[id])
+	}
+	return messages
+}
+
+// toDeadLetter reads the failure details DeadLetter attached to a message.
+func toDeadLetter(m ports.Message) ports.DeadLetter {
+	failedAt, _ := time.Parse(time.RFC3339, m.Attributes[failedAtAttribute])
+	attributes := maps.Clone(m.Attributes)
+	delete(attributes, failureReasonAttribute)
+	delete(attributes, failedAtAttribute)
+
+	return ports.DeadLetter{
+		ID:           m.ID,
+		Body:         m.Body,
+		Attributes:   attributes,
+		Reason:       m.Attributes[failureReasonAttribute],
+		FailedAt:     failedAt,
+		ReceiveCount: m.ReceiveCount,
+	}
+}
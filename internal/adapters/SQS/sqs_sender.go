@@ -2,36 +2,93 @@ package sqsadapter
 
 import (
 	"context"
+	"strconv"
+	"sync"
+	"time"
 
 	"checkin.service/internal/ports"
-	"checkin.service/pkg/telemetry"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
-// SQSSender implements MessageSender for AWS SQS.
+// SQSSender implements MessageSender for AWS SQS. Messages to the same queue that
+// are sent within Linger of each other go out in one SendMessageBatch call.
 type SQSSender struct {
 	client SQSClient
+	linger time.Duration
+
+	mu       sync.Mutex
+	batchers map[string]*batcher[types.SendMessageBatchRequestEntry]
 }
 
 // SQSClient defines the interface for the AWS SQS client.
 type SQSClient interface {
-	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+}
+
+// NewSQSSender creates a sender that waits up to linger for more messages to the same
+// queue before sending a batch. A zero linger sends every message on its own.
+func NewSQSSender(client SQSClient, linger time.Duration) *SQSSender {
+	return &SQSSender{
+		client:   client,
+		linger:   linger,
+		batchers: make(map[string]*batcher[types.SendMessageBatchRequestEntry]),
+	}
 }
 
-func (s *SQSSender) SendMessage(ctx context.Context, destination string, body []byte) error {
-	// Inject trace context into message attributes
-	attributes := telemetry.InjectTraceContext(ctx)
+func (s *SQSSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	options := ports.ApplySendOptions(opts)
 
-	_, err := s.client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:          aws.String(destination),
+	attributes := MessageAttributes(ctx, options.Attributes)
+
+	entry := types.SendMessageBatchRequestEntry{
 		MessageBody:       aws.String(string(body)),
 		MessageAttributes: attributes,
-	})
-	return err
+	}
+	if IsFIFO(destination) {
+		entry.MessageGroupId, entry.MessageDeduplicationId = FIFOFields(options)
+	}
+
+	size := len(body)
+	for k, v := range attributes {
+		size += len(k) + len(aws.ToString(v.DataType)) + len(aws.ToString(v.StringValue))
+	}
+	return s.batcher(destination).do(ctx, entry, size)
+}
+
+// batcher returns the send batcher of a queue.
+func (s *SQSSender) batcher(queueURL string) *batcher[types.SendMessageBatchRequestEntry] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.batchers[queueURL]
+	if !ok {
+		b = newBatcher(s.linger, func(ctx context.Context, entries []types.SendMessageBatchRequestEntry) []error {
+			for i := range entries {
+				entries[i].Id = aws.String(strconv.Itoa(i))
+			}
+			output, err := s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+				QueueUrl: aws.String(queueURL),
+				Entries:  entries,
+			})
+			if err != nil {
+				return repeatErr(len(entries), err)
+			}
+
+			successful := make([]string, 0, len(output.Successful))
+			for _, e := range output.Successful {
+				successful = append(successful, aws.ToString(e.Id))
+			}
+			return batchResults(len(entries), successful, failedEntries(output.Failed))
+		})
+		s.batchers[queueURL] = b
+	}
+	return b
 }
 
-// NewSQSProducer creates a new Producer backed by an AWS SQS sender.
-func NewSQSProducer(client SQSClient, laborQueueURL, emailQueueURL string) *ports.Producer {
-	return ports.NewProducer(&SQSSender{client: client}, laborQueueURL, emailQueueURL)
+// NewSQSProducer creates a new Producer backed by an AWS SQS sender that batches
+// messages sent within linger of each other.
+func NewSQSProducer(client SQSClient, routes ports.Routes, linger time.Duration) *ports.Producer {
+	return ports.NewProducer(NewSQSSender(client, linger), routes)
 }
@@ -0,0 +1,136 @@
+package sqsadapter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// fakeBatchClient records SendMessageBatch calls. Entries with the body "fail" are
+// rejected; with err set the whole request fails.
+type fakeBatchClient struct {
+	mu      sync.Mutex
+	batches [][]types.SendMessageBatchRequestEntry
+	err     error
+}
+
+func (c *fakeBatchClient) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, params.Entries)
+	c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	output := &sqs.SendMessageBatchOutput{}
+	for _, e := range params.Entries {
+		if aws.ToString(e.MessageBody) == "fail" {
+			output.Failed = append(output.Failed, types.BatchResultErrorEntry{
+				Id:      e.Id,
+				Code:    aws.String("InvalidMessageContents"),
+				Message: aws.String("rejected"),
+			})
+			continue
+		}
+		output.Successful = append(output.Successful, types.SendMessageBatchResultEntry{Id: e.Id})
+	}
+	return output, nil
+}
+
+func TestSQSSenderBatches(t *testing.T) {
+	errRequest := errors.New("request failed")
+	large := strings.Repeat("x", 100*1024)
+
+	tests := []struct {
+		name   string
+		bodies []string
+		err    error
+		// wantBatches are the sizes of the batch requests, largest first.
+		wantBatches []int
+		wantFailed  []int
+	}{
+		{
+			name:        "at most 10 entries per request",
+			bodies:      slices.Repeat([]string{"ok"}, 25),
+			wantBatches: []int{10, 10, 5},
+		},
+		{
+			name:        "at most 256 KiB per request",
+			bodies:      []string{large, large, large},
+			wantBatches: []int{2, 1},
+		},
+		{
+			name:        "partial failure only fails the rejected entry",
+			bodies:      []string{"ok", "fail", "ok"},
+			wantBatches: []int{3},
+			wantFailed:  []int{1},
+		},
+		{
+			name:        "failed request fails every entry",
+			bodies:      []string{"ok", "ok"},
+			err:         errRequest,
+			wantBatches: []int{2},
+			wantFailed:  []int{0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeBatchClient{err: tt.err}
+			sender := NewSQSSender(client, 100*time.Millisecond)
+
+			errs := make([]error, len(tt.bodies))
+			var wg sync.WaitGroup
+			for i, body := range tt.bodies {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = sender.SendMessage(context.Background(), "queue-url", []byte(body))
+				}()
+			}
+			wg.Wait()
+
+			var sizes []int
+			for _, b := range client.batches {
+				sizes = append(sizes, len(b))
+			}
+			slices.Sort(sizes)
+			slices.Reverse(sizes)
+			if !slices.Equal(sizes, tt.wantBatches) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.wantBatches)
+			}
+
+			var failed []int
+			for i, err := range errs {
+				if err != nil {
+					failed = append(failed, i)
+				}
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed entries = %v, want %v (errors %v)", failed, tt.wantFailed, errs)
+			}
+		})
+	}
+}
+
+func TestBatchResults(t *testing.T) {
+	errRejected := errors.New("rejected")
+	errs := batchResults(3, []string{"0"}, map[string]error{"1": errRejected})
+
+	if errs[0] != nil {
+		t.Errorf("entry 0 error = %v, want nil", errs[0])
+	}
+	if !errors.Is(errs[1], errRejected) {
+		t.Errorf("entry 1 error = %v, want %v", errs[1], errRejected)
+	}
+	if !errors.Is(errs[2], errMissingBatchResult) {
+		t.Errorf("entry 2 error = %v, want %v", errs[2], errMissingBatchResult)
+	}
+}
@@ -0,0 +1,247 @@
+package sqsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// maxReceiveBatch is the largest MaxNumberOfMessages SQS accepts.
+const maxReceiveBatch = 10
+
+// Attributes DeadLetter adds to a dead-lettered message.
+const (
+	failureReasonAttribute = "failure_reason"
+	failedAtAttribute      = "failed_at"
+	sourceQueueAttribute   = "source_queue"
+)
+
+// SQSConsumerClient defines the part of the AWS SQS client a SQSSource needs.
+type SQSConsumerClient interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+// SQSSource implements ports.MessageSource for a single SQS queue. Acks, retries and
+// heartbeats of concurrently processed messages that happen within linger of each
+// other are sent as one DeleteMessageBatch or ChangeMessageVisibilityBatch call.
+type SQSSource struct {
+	client   SQSConsumerClient
+	queueURL string
+	// DeadLetterQueueURL receives messages passed to DeadLetter. If empty, DeadLetter
+	// fails and such messages are left for the queue's redrive policy.
+	DeadLetterQueueURL string
+	// WaitTimeSeconds is the long-polling wait of each receive.
+	WaitTimeSeconds int32
+
+	deletes      *batcher[types.DeleteMessageBatchRequestEntry]
+	visibilities *batcher[types.ChangeMessageVisibilityBatchRequestEntry]
+}
+
+// NewSQSSource creates a message source that long-polls the given queue and batches
+// the calls made within linger of each other. A zero linger disables batching.
+func NewSQSSource(client SQSConsumerClient, queueURL string, linger time.Duration) *SQSSource {
+	s := &SQSSource{
+		client:          client,
+		queueURL:        queueURL,
+		WaitTimeSeconds: 20,
+	}
+	s.deletes = newBatcher(linger, s.deleteBatch)
+	s.visibilities = newBatcher(linger, s.changeVisibilityBatch)
+	return s
+}
+
+// System names the broker for traces.
+func (s *SQSSource) System() string {
+	return "aws_sqs"
+}
+
+func (s *SQSSource) Receive(ctx context.Context, max int) ([]ports.Message, error) {
+	if max > maxReceiveBatch {
+		max = maxReceiveBatch
+	}
+	output, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              &s.queueURL,
+		MaxNumberOfMessages:   int32(max),
+		WaitTimeSeconds:       s.WaitTimeSeconds,
+		MessageAttributeNames: []string{"All"}, // Request attributes to get trace context
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameMessageGroupId,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]ports.Message, 0, len(output.Messages))
+	for _, msg := range output.Messages {
+		messages = append(messages, fromSQSMessage(msg))
+	}
+	return messages, nil
+}
+
+func (s *SQSSource) Ack(ctx context.Context, msg ports.Message) error {
+	return s.deletes.do(ctx, types.DeleteMessageBatchRequestEntry{ReceiptHandle: aws.String(msg.Handle)}, 0)
+}
+
+func (s *SQSSource) Nack(ctx context.Context, msg ports.Message, delay time.Duration) error {
+	return s.changeVisibility(ctx, msg, delay)
+}
+
+func (s *SQSSource) Extend(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	return s.changeVisibility(ctx, msg, timeout)
+}
+
+// ApproximateDepth returns the queue's ApproximateNumberOfMessages, the messages
+// that are visible and waiting to be received.
+func (s *SQSSource) ApproximateDepth(ctx context.Context) (int, error) {
+	output, err := s.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       &s.queueURL,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(output.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+}
+
+// DeadLetter copies a message to the dead-letter queue with the failure reason as a
+// message attribute and then deletes it from the source queue. If the copy fails the
+// message stays on the source queue and is retried on its next delivery.
+func (s *SQSSource) DeadLetter(ctx context.Context, msg ports.Message, reason string) error {
+	if s.DeadLetterQueueURL == "" {
+		return errors.New("no dead-letter queue configured")
+	}
+
+	attributes := make(map[string]types.MessageAttributeValue, len(msg.Attributes)+3)
+	for k, v := range msg.Attributes {
+		attributes[k] = stringAttribute(v)
+	}
+	attributes[failureReasonAttribute] = stringAttribute(reason)
+	attributes[failedAtAttribute] = stringAttribute(time.Now().UTC().Format(time.RFC3339))
+	attributes[sourceQueueAttribute] = stringAttribute(s.queueURL)
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          &s.DeadLetterQueueURL,
+		MessageBody:       aws.String(string(msg.Body)),
+		MessageAttributes: attributes,
+	}
+	if IsFIFO(s.DeadLetterQueueURL) {
+		input.MessageGroupId, input.MessageDeduplicationId = FIFOFields(ports.SendOptions{OrderingKey: msg.GroupID, DeduplicationID: msg.ID})
+	}
+
+	_, err := s.client.SendMessage(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return s.Ack(ctx, msg)
+}
+
+func (s *SQSSource) changeVisibility(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	return s.visibilities.do(ctx, types.ChangeMessageVisibilityBatchRequestEntry{
+		ReceiptHandle:     aws.String(msg.Handle),
+		VisibilityTimeout: int32(timeout.Seconds()),
+	}, 0)
+}
+
+func (s *SQSSource) deleteBatch(ctx context.Context, entries []types.DeleteMessageBatchRequestEntry) []error {
+	for i := range entries {
+		entries[i].Id = aws.String(strconv.Itoa(i))
+	}
+	output, err := s.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: &s.queueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		return repeatErr(len(entries), err)
+	}
+
+	successful := make([]string, 0, len(output.Successful))
+	for _, e := range output.Successful {
+		successful = append(successful, aws.ToString(e.Id))
+	}
+	return batchResults(len(entries), successful, failedEntries(output.Failed))
+}
+
+func (s *SQSSource) changeVisibilityBatch(ctx context.Context, entries []types.ChangeMessageVisibilityBatchRequestEntry) []error {
+	for i := range entries {
+		entries[i].Id = aws.String(strconv.Itoa(i))
+	}
+	output, err := s.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: &s.queueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		return repeatErr(len(entries), err)
+	}
+
+	successful := make([]string, 0, len(output.Successful))
+	for _, e := range output.Successful {
+		successful = append(successful, aws.ToString(e.Id))
+	}
+	return batchResults(len(entries), successful, failedEntries(output.Failed))
+}
+
+func fromSQSMessage(msg types.Message) ports.Message {
+	attributes := make(map[string]string, len(msg.MessageAttributes))
+	for k, v := range msg.MessageAttributes {
+		if v.StringValue != nil {
+			attributes[k] = *v.StringValue
+		}
+	}
+	receiveCount, _ := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+
+	body := []byte(aws.ToString(msg.Body))
+	if n, ok := parseSNSNotification(body); ok {
+		body = []byte(n.Message)
+		for k, v := range n.MessageAttributes {
+			if v.Type == "String" {
+				attributes[k] = v.Value
+			}
+		}
+	}
+
+	return ports.Message{
+		ID:           aws.ToString(msg.MessageId),
+		Body:         body,
+		Attributes:   attributes,
+		Handle:       aws.ToString(msg.ReceiptHandle),
+		ReceiveCount: receiveCount,
+		GroupID:      msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)],
+	}
+}
+
+// snsNotification is the JSON document SNS delivers to a subscribed queue when raw
+// message delivery is off. The published message is in Message and its message
+// attributes, among them the trace context, in MessageAttributes.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	TopicArn          string `json:"TopicArn"`
+	Message           string `json:"Message"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// parseSNSNotification reports whether body is an SNS notification. Messages sent
+// to the queue directly are left as they are.
+func parseSNSNotification(body []byte) (snsNotification, bool) {
+	var n snsNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return n, false
+	}
+	return n, n.Type == "Notification" && n.TopicArn != ""
+}
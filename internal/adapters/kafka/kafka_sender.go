@@ -0,0 +1,71 @@
+// Package kafkaadapter connects the service to Kafka: a MessageSender that keys records
+// by employee ID, and a MessageSource that commits offsets in order and implements
+// delayed retries and dead-lettering with a retry topic and a dead-letter topic (DLT).
+package kafkaadapter
+
+import (
+	"context"
+
+	"checkin.service/internal/ports"
+	"checkin.service/pkg/telemetry"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSender implements ports.MessageSender. The destination is the topic name and the
+// ordering key is the record key, so all events of one employee land on one partition.
+type KafkaSender struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSender(brokers []string) *KafkaSender {
+	return &KafkaSender{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (s *KafkaSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	options := ports.ApplySendOptions(opts)
+
+	// Inject trace context into the record headers
+	attributes := telemetry.InjectTraceAttributes(ctx)
+	for k, v := range options.Attributes {
+		attributes[k] = v
+	}
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   destination,
+		Key:     []byte(options.OrderingKey),
+		Value:   body,
+		Headers: toHeaders(attributes),
+	})
+}
+
+// Close flushes pending writes and closes the connections.
+func (s *KafkaSender) Close() error {
+	return s.writer.Close()
+}
+
+// NewKafkaProducer creates a new Producer backed by a Kafka sender.
+func NewKafkaProducer(brokers []string, routes ports.Routes) *ports.Producer {
+	return ports.NewProducer(NewKafkaSender(brokers), routes)
+}
+
+func toHeaders(attributes map[string]string) []kafka.Header {
+	headers := make([]kafka.Header, 0, len(attributes))
+	for k, v := range attributes {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return headers
+}
+
+func fromHeaders(headers []kafka.Header) map[string]string {
+	attributes := make(map[string]string, len(headers))
+	for _, h := range headers {
+		attributes[h.Key] = string(h.Value)
+	}
+	return attributes
+}
@@ -0,0 +1,372 @@
+package kafkaadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers the source adds to records it moves to the retry topic or the DLT.
+const (
+	headerAttempt       = "x-attempt"
+	headerNotBefore     = "x-not-before"
+	headerFailureReason = "failure_reason"
+	headerFailedAt      = "failed_at"
+	headerSourceTopic   = "source_topic"
+)
+
+// KafkaSource implements ports.MessageSource on a topic and its retry topic
+// (<topic>.retry), consumed by one consumer group.
+//
+// Kafka cannot hide a single record, so a Nack republishes the record to the retry
+// topic with a not-before time and settles the original; records from the retry topic
+// are held back until that time. DeadLetter publishes to <topic>.dlt.
+// Offsets are committed in order: a record is only committed once it and every
+// earlier record of its partition have been settled, so a crash replays anything
+// that was still in flight. Retried records can overtake newer records of the same
+// employee, so consumers must stay idempotent and skip records older than what they
+// already processed, as the labor processor does by the shift's version.
+type KafkaSource struct {
+	topic      string
+	retryTopic string
+	deadTopic  string
+	readers    []*kafka.Reader
+	writer     *kafka.Writer
+
+	fetched chan kafka.Message
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[partitionKey]*partitionState
+
+	// WaitTime is the longest Receive blocks when no record is available.
+	WaitTime time.Duration
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// partitionState tracks the fetched but not yet committed records of one partition.
+type partitionState struct {
+	offsets []int64 // ascending
+	records map[int64]kafka.Message
+	settled map[int64]bool
+}
+
+// NewKafkaSource starts consuming topic and its retry topic as group. Close must be
+// called once the worker using the source has stopped.
+func NewKafkaSource(brokers []string, group, topic string) *KafkaSource {
+	s := &KafkaSource{
+		topic:      topic,
+		retryTopic: topic + ".retry",
+		deadTopic:  topic + ".dlt",
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		fetched:  make(chan kafka.Message),
+		pending:  make(map[partitionKey]*partitionState),
+		WaitTime: time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, t := range []string{s.topic, s.retryTopic} {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			GroupID: group,
+			Topic:   t,
+		})
+		s.readers = append(s.readers, reader)
+		s.done.Add(1)
+		go s.fetch(ctx, reader, t == s.retryTopic)
+	}
+	return s
+}
+
+// fetch hands records of one reader to Receive. Records from the retry topic are
+// held back until their not-before time.
+func (s *KafkaSource) fetch(ctx context.Context, reader *kafka.Reader, delayed bool) {
+	defer s.done.Done()
+
+	for {
+		record, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error().Err(err).Str("topic", reader.Config().Topic).Msg("Error fetching Kafka record")
+			continue
+		}
+
+		if delayed {
+			if notBefore, err := time.Parse(time.RFC3339Nano, header(record, headerNotBefore)); err == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(notBefore)):
+				}
+			}
+		}
+
+		s.track(record)
+		select {
+		case <-ctx.Done():
+			return
+		case s.fetched <- record:
+		}
+	}
+}
+
+func (s *KafkaSource) track(record kafka.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := partitionKey{topic: record.Topic, partition: record.Partition}
+	state, ok := s.pending[key]
+	if ok && len(state.offsets) > 0 && record.Offset <= state.offsets[len(state.offsets)-1] {
+		// The partition was reassigned and replays from its committed offset; what we
+		// were tracking for it can no longer be committed.
+		ok = false
+	}
+	if !ok {
+		state = &partitionState{records: make(map[int64]kafka.Message), settled: make(map[int64]bool)}
+		s.pending[key] = state
+	}
+	state.offsets = append(state.offsets, record.Offset)
+	state.records[record.Offset] = record
+}
+
+// System names the broker for traces.
+func (s *KafkaSource) System() string {
+	return "kafka"
+}
+
+func (s *KafkaSource) Receive(ctx context.Context, max int) ([]ports.Message, error) {
+	timer := time.NewTimer(s.WaitTime)
+	defer timer.Stop()
+
+	var messages []ports.Message
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, nil
+	case record := <-s.fetched:
+		messages = append(messages, toMessage(record))
+	}
+
+	// Take whatever else is ready without waiting.
+	for len(messages) < max {
+		select {
+		case record := <-s.fetched:
+			messages = append(messages, toMessage(record))
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
+
+func (s *KafkaSource) Ack(ctx context.Context, msg ports.Message) error {
+	return s.settle(ctx, msg)
+}
+
+// Nack republishes the record to the retry topic, to be redelivered after delay, and
+// commits the original. A zero delay releases a record that was not processed, e.g. the
+// rest of a group after a retry: it is redelivered at once and keeps its attempt count.
+func (s *KafkaSource) Nack(ctx context.Context, msg ports.Message, delay time.Duration) error {
+	record, err := s.record(msg)
+	if err != nil {
+		return err
+	}
+	attempt := msg.ReceiveCount
+	if delay <= 0 {
+		attempt--
+	}
+	headers := withHeaders(record.Headers, map[string]string{
+		headerAttempt:   strconv.Itoa(attempt),
+		headerNotBefore: time.Now().Add(delay).UTC().Format(time.RFC3339Nano),
+	})
+	err = s.writer.WriteMessages(ctx, kafka.Message{Topic: s.retryTopic, Key: record.Key, Value: record.Value, Headers: headers})
+	if err != nil {
+		return fmt.Errorf("failed to publish to retry topic: %w", err)
+	}
+	return s.settle(ctx, msg)
+}
+
+// Extend is a no-op: a fetched record stays assigned to this consumer until its
+// partition is rebalanced.
+func (s *KafkaSource) Extend(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	return nil
+}
+
+func (s *KafkaSource) DeadLetter(ctx context.Context, msg ports.Message, reason string) error {
+	record, err := s.record(msg)
+	if err != nil {
+		return err
+	}
+	headers := withHeaders(record.Headers, map[string]string{
+		headerFailureReason: reason,
+		headerFailedAt:      time.Now().UTC().Format(time.RFC3339),
+		headerSourceTopic:   s.topic,
+	})
+	err = s.writer.WriteMessages(ctx, kafka.Message{Topic: s.deadTopic, Key: record.Key, Value: record.Value, Headers: headers})
+	if err != nil {
+		return fmt.Errorf("failed to publish to dead-letter topic: %w", err)
+	}
+	return s.settle(ctx, msg)
+}
+
+// Close stops fetching and closes the readers and the writer.
+func (s *KafkaSource) Close() error {
+	s.cancel()
+	s.done.Wait()
+
+	errs := []error{s.writer.Close()}
+	for _, reader := range s.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+var errUnknownRecord = errors.New("record is not pending on this source")
+
+func (s *KafkaSource) record(msg ports.Message) (kafka.Message, error) {
+	key, offset, err := parseHandle(msg.Handle)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.pending[key]
+	if !ok {
+		return kafka.Message{}, errUnknownRecord
+	}
+	record, ok := state.records[offset]
+	if !ok {
+		return kafka.Message{}, errUnknownRecord
+	}
+	return record, nil
+}
+
+// settle marks a record as done and commits the highest offset of its partition up to
+// which every record is settled.
+func (s *KafkaSource) settle(ctx context.Context, msg ports.Message) error {
+	key, offset, err := parseHandle(msg.Handle)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	state, ok := s.pending[key]
+	if !ok || !containsOffset(state.offsets, offset) {
+		s.mu.Unlock()
+		return errUnknownRecord
+	}
+	state.settled[offset] = true
+
+	var commit *kafka.Message
+	for len(state.offsets) > 0 && state.settled[state.offsets[0]] {
+		first := state.offsets[0]
+		record := state.records[first]
+		commit = &record
+		state.offsets = state.offsets[1:]
+		delete(state.records, first)
+		delete(state.settled, first)
+	}
+	s.mu.Unlock()
+
+	if commit == nil {
+		return nil
+	}
+	return s.reader(commit.Topic).CommitMessages(ctx, *commit)
+}
+
+func (s *KafkaSource) reader(topic string) *kafka.Reader {
+	if topic == s.retryTopic {
+		return s.readers[1]
+	}
+	return s.readers[0]
+}
+
+func containsOffset(offsets []int64, offset int64) bool {
+	i := sort.Search(len(offsets), func(i int) bool { return offsets[i] >= offset })
+	return i < len(offsets) && offsets[i] == offset
+}
+
+func toMessage(record kafka.Message) ports.Message {
+	attempt, _ := strconv.Atoi(header(record, headerAttempt))
+	handle := fmt.Sprintf("%s/%d/%d", record.Topic, record.Partition, record.Offset)
+	return ports.Message{
+		ID:           handle,
+		Body:         record.Value,
+		Attributes:   fromHeaders(record.Headers),
+		Handle:       handle,
+		ReceiveCount: attempt + 1,
+		GroupID:      string(record.Key),
+	}
+}
+
+func parseHandle(handle string) (partitionKey, int64, error) {
+	var (
+		key    partitionKey
+		offset int64
+	)
+	// Topic names cannot contain '/', so the last two segments are partition and offset.
+	last := strings.LastIndexByte(handle, '/')
+	if last < 0 {
+		return key, 0, fmt.Errorf("invalid Kafka handle %q", handle)
+	}
+	middle := strings.LastIndexByte(handle[:last], '/')
+	if middle < 0 {
+		return key, 0, fmt.Errorf("invalid Kafka handle %q", handle)
+	}
+	partition, err := strconv.Atoi(handle[middle+1 : last])
+	if err != nil {
+		return key, 0, fmt.Errorf("invalid Kafka handle %q", handle)
+	}
+	offset, err = strconv.ParseInt(handle[last+1:], 10, 64)
+	if err != nil {
+		return key, 0, fmt.Errorf("invalid Kafka handle %q", handle)
+	}
+	return partitionKey{topic: handle[:middle], partition: partition}, offset, nil
+}
+
+func header(record kafka.Message, key string) string {
+	for _, h := range record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// withHeaders returns headers with values set, replacing existing keys.
+func withHeaders(headers []kafka.Header, values map[string]string) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers)+len(values))
+	for _, h := range headers {
+		if _, ok := values[h.Key]; !ok {
+			out = append(out, h)
+		}
+	}
+	for k, v := range values {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package kafkaadapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+// newTestSource creates a source on a fresh topic of the broker in KAFKA_TEST_BROKERS
+// and a sender for it. The test is skipped when the variable is not set.
+func newTestSource(t *testing.T) (*KafkaSource, *KafkaSender, string, []string) {
+	t.Helper()
+	env := os.Getenv("KAFKA_TEST_BROKERS")
+	if env == "" {
+		t.Skip("KAFKA_TEST_BROKERS is not set")
+	}
+	brokers := strings.Split(env, ",")
+
+	topic := fmt.Sprintf("test-%s-%d", strings.ToLower(t.Name()), time.Now().UnixNano())
+	sender := NewKafkaSender(brokers)
+	t.Cleanup(func() { _ = sender.Close() })
+	source := NewKafkaSource(brokers, topic+"-group", topic)
+	t.Cleanup(func() { _ = source.Close() })
+	return source, sender, topic, brokers
+}
+
+// receive waits up to 30 seconds for the next record; a new topic and consumer group
+// take a while to become ready.
+func receive(t *testing.T, source *KafkaSource) ports.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for {
+		messages, err := source.Receive(ctx, 1)
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		if len(messages) > 0 {
+			return messages[0]
+		}
+	}
+}
+
+func send(t *testing.T, sender *KafkaSender, topic, body string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sender.SendMessage(ctx, topic, []byte(body), ports.WithOrderingKey("emp-1")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+}
+
+func TestKafkaSourceReleaseRedelivers(t *testing.T) {
+	source, sender, topic, _ := newTestSource(t)
+	send(t, sender, topic, "released")
+
+	msg := receive(t, source)
+	if err := source.Nack(context.Background(), msg, 0); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	redelivered := receive(t, source)
+	if string(redelivered.Body) != "released" {
+		t.Errorf("redelivered %q, want %q", redelivered.Body, "released")
+	}
+	if redelivered.ReceiveCount != 1 {
+		t.Errorf("ReceiveCount = %d after a release, want 1", redelivered.ReceiveCount)
+	}
+}
+
+func TestKafkaSourceRetryWaitsForDelay(t *testing.T) {
+	source, sender, topic, _ := newTestSource(t)
+	send(t, sender, topic, "retried")
+
+	msg := receive(t, source)
+	nacked := time.Now()
+	if err := source.Nack(context.Background(), msg, 2*time.Second); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	redelivered := receive(t, source)
+	if elapsed := time.Since(nacked); elapsed < 2*time.Second {
+		t.Errorf("redelivered after %v, want at least 2s", elapsed)
+	}
+	if redelivered.ReceiveCount != 2 {
+		t.Errorf("ReceiveCount = %d after a retry, want 2", redelivered.ReceiveCount)
+	}
+}
+
+func TestKafkaSourceDeadLetter(t *testing.T) {
+	source, sender, topic, brokers := newTestSource(t)
+	send(t, sender, topic, "dead")
+
+	msg := receive(t, source)
+	if err := source.DeadLetter(context.Background(), msg, "gave up"); err != nil {
+		t.Fatalf("DeadLetter() error = %v", err)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Topic: topic + ".dlt"})
+	defer reader.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	record, err := reader.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("reading the dead-letter topic: %v", err)
+	}
+	if string(record.Value) != "dead" {
+		t.Errorf("dead letter = %q, want %q", record.Value, "dead")
+	}
+	if got := header(record, headerFailureReason); got != "gave up" {
+		t.Errorf("%s = %q, want %q", headerFailureReason, got, "gave up")
+	}
+	if got := header(record, headerSourceTopic); got != topic {
+		t.Errorf("%s = %q, want %q", headerSourceTopic, got, topic)
+	}
+}
@@ -0,0 +1,252 @@
+// Package memory provides an in-process message broker for the all-in-one binary,
+// demos and integration tests. Messages live only as long as the process.
+package memory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"checkin.service/internal/ports"
+	"checkin.service/pkg/telemetry"
+)
+
+// DeadLetter is a message that was moved out of its queue.
+type DeadLetter struct {
+	Queue    string
+	Message  ports.Message
+	Reason   string
+	FailedAt time.Time
+}
+
+// Broker holds named in-memory queues. It implements ports.MessageSender, with the
+// destination being the queue name, and hands out a ports.MessageSource per queue.
+type Broker struct {
+	mu          sync.Mutex
+	queues      map[string]*memQueue
+	deadLetters []DeadLetter
+	// idPrefix makes message IDs unique across restarts, so a message quarantined by
+	// an earlier run is not mistaken for a new message with the same number.
+	idPrefix  string
+	nextID    int64
+	nextToken int64
+}
+
+type memQueue struct {
+	entries []*entry
+	// wake is signaled whenever a message is sent or released.
+	wake chan struct{}
+}
+
+type entry struct {
+	msg       ports.Message
+	visibleAt time.Time
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		queues:   make(map[string]*memQueue),
+		idPrefix: strconv.FormatInt(time.Now().UnixNano(), 36) + "-",
+	}
+}
+
+// queue returns the named queue, creating it on first use. b.mu must be held.
+func (b *Broker) queue(name string) *memQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memQueue{wake: make(chan struct{}, 1)}
+		b.queues[name] = q
+	}
+	return q
+}
+
+func (q *memQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Broker) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	attributes := telemetry.InjectTraceAttributes(ctx)
+	for k, v := range ports.ApplySendOptions(opts).Attributes {
+		attributes[k] = v
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	q := b.queue(destination)
+	q.entries = append(q.entries, &entry{
+		msg: ports.Message{
+			ID:         b.idPrefix + strconv.FormatInt(b.nextID, 10),
+			Body:       body,
+			Attributes: attributes,
+		},
+		visibleAt: time.Now(),
+	})
+	q.signal()
+	return nil
+}
+
+// DeadLetters returns the messages dead-lettered so far, oldest first.
+func (b *Broker) DeadLetters() []DeadLetter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]DeadLetter(nil), b.deadLetters...)
+}
+
+// Source returns a message source consuming the named queue.
+func (b *Broker) Source(name string) *Source {
+	return &Source{
+		broker:            b,
+		name:              name,
+		VisibilityTimeout: 30 * time.Second,
+		WaitTime:          time.Second,
+	}
+}
+
+// Source implements ports.MessageSource on one queue of a Broker.
+type Source struct {
+	broker *Broker
+	name   string
+	// VisibilityTimeout is how long a received message stays hidden unless extended.
+	VisibilityTimeout time.Duration
+	// WaitTime is the longest Receive blocks when the queue is empty.
+	WaitTime time.Duration
+}
+
+// System names the broker for traces.
+func (s *Source) System() string {
+	return "memory"
+}
+
+func (s *Source) Receive(ctx context.Context, max int) ([]ports.Message, error) {
+	deadline := time.NewTimer(s.WaitTime)
+	defer deadline.Stop()
+
+	for {
+		messages, wake, nextVisible := s.claim(max)
+		if len(messages) > 0 {
+			return messages, nil
+		}
+
+		var retry *time.Timer
+		var retryC <-chan time.Time
+		if !nextVisible.IsZero() {
+			retry = time.NewTimer(time.Until(nextVisible))
+			retryC = retry.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, nil
+		case <-wake:
+		case <-retryC:
+		}
+		if retry != nil {
+			retry.Stop()
+		}
+	}
+}
+
+// claim hides up to max visible messages and gives each a new delivery handle. If none
+// are visible it returns the queue's wake channel and when the next hidden one reappears.
+func (s *Source) claim(max int) ([]ports.Message, <-chan struct{}, time.Time) {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(s.name)
+	now := time.Now()
+	var (
+		messages    []ports.Message
+		nextVisible time.Time
+	)
+	for _, e := range q.entries {
+		if e.visibleAt.After(now) {
+			if nextVisible.IsZero() || e.visibleAt.Before(nextVisible) {
+				nextVisible = e.visibleAt
+			}
+			continue
+		}
+		if len(messages) == max {
+			break
+		}
+		b.nextToken++
+		e.msg.Handle = strconv.FormatInt(b.nextToken, 10)
+		e.msg.ReceiveCount++
+		e.visibleAt = now.Add(s.VisibilityTimeout)
+		messages = append(messages, e.msg)
+	}
+	return messages, q.wake, nextVisible
+}
+
+func (s *Source) Ack(ctx context.Context, msg ports.Message) error {
+	_, err := s.settle(msg, func(q *memQueue, i int) {
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+	})
+	return err
+}
+
+func (s *Source) Nack(ctx context.Context, msg ports.Message, delay time.Duration) error {
+	_, err := s.settle(msg, func(q *memQueue, i int) {
+		q.entries[i].msg.Handle = ""
+		q.entries[i].visibleAt = time.Now().Add(delay)
+		q.signal()
+	})
+	return err
+}
+
+func (s *Source) Extend(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	_, err := s.settle(msg, func(q *memQueue, i int) {
+		q.entries[i].visibleAt = time.Now().Add(timeout)
+	})
+	return err
+}
+
+func (s *Source) DeadLetter(ctx context.Context, msg ports.Message, reason string) error {
+	e, err := s.settle(msg, func(q *memQueue, i int) {
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.deadLetters = append(s.broker.deadLetters, DeadLetter{
+		Queue:    s.name,
+		Message:  e.msg,
+		Reason:   reason,
+		FailedAt: time.Now(),
+	})
+	return nil
+}
+
+// errStaleHandle is returned when a message was redelivered or removed since msg was received.
+var errStaleHandle = errors.New("message handle is no longer valid")
+
+// settle applies fn to the entry of msg if msg still holds its current delivery handle.
+func (s *Source) settle(msg ports.Message, fn func(q *memQueue, i int)) (*entry, error) {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(s.name)
+	for i, e := range q.entries {
+		if e.msg.ID == msg.ID {
+			if msg.Handle == "" || e.msg.Handle != msg.Handle {
+				return nil, errStaleHandle
+			}
+			fn(q, i)
+			return e, nil
+		}
+	}
+	return nil, errStaleHandle
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/worker"
+)
+
+func receiveOne(t *testing.T, source *Source) ports.Message {
+	t.Helper()
+	messages, err := source.Receive(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Receive() returned %d messages, want 1", len(messages))
+	}
+	return messages[0]
+}
+
+func TestSourceSettlesOnlyCurrentDelivery(t *testing.T) {
+	broker := NewBroker()
+	source := broker.Source("labor")
+	source.VisibilityTimeout = 10 * time.Millisecond
+	source.WaitTime = time.Second
+
+	if err := broker.SendMessage(context.Background(), "labor", []byte("body")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	first := receiveOne(t, source)
+	// The visibility timeout expires and the message is delivered again.
+	second := receiveOne(t, source)
+	if second.ReceiveCount != 2 {
+		t.Errorf("ReceiveCount = %d, want 2", second.ReceiveCount)
+	}
+
+	if err := source.Ack(context.Background(), first); !errors.Is(err, errStaleHandle) {
+		t.Errorf("Ack() of the expired delivery error = %v, want %v", err, errStaleHandle)
+	}
+	if err := source.Ack(context.Background(), second); err != nil {
+		t.Errorf("Ack() of the current delivery error = %v", err)
+	}
+}
+
+func TestSourceNackDelaysRedelivery(t *testing.T) {
+	broker := NewBroker()
+	source := broker.Source("labor")
+	source.WaitTime = 20 * time.Millisecond
+
+	if err := broker.SendMessage(context.Background(), "labor", []byte("body")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	msg := receiveOne(t, source)
+	if err := source.Nack(context.Background(), msg, time.Hour); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	messages, err := source.Receive(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("Receive() returned %d messages during the delay, want 0", len(messages))
+	}
+}
+
+func TestSourceDeadLetter(t *testing.T) {
+	broker := NewBroker()
+	source := broker.Source("email")
+
+	if err := broker.SendMessage(context.Background(), "email", []byte("body")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	msg := receiveOne(t, source)
+	if err := source.DeadLetter(context.Background(), msg, "gave up"); err != nil {
+		t.Fatalf("DeadLetter() error = %v", err)
+	}
+
+	deadLetters := broker.DeadLetters()
+	if len(deadLetters) != 1 {
+		t.Fatalf("DeadLetters() returned %d messages, want 1", len(deadLetters))
+	}
+	if got := deadLetters[0]; got.Queue != "email" || got.Reason != "gave up" || got.Message.ID != msg.ID {
+		t.Errorf("dead letter = %s %q %s, want email %q %s", got.Queue, got.Reason, got.Message.ID, "gave up", msg.ID)
+	}
+}
+
+// recordingProcessor records the event types it processes and fails the first
+// attempt of every message.
+type recordingProcessor struct {
+	mu        sync.Mutex
+	attempts  map[string]int
+	processed chan string
+}
+
+func (p *recordingProcessor) Process(ctx context.Context, msg ports.Message) (bool, int32, error) {
+	p.mu.Lock()
+	p.attempts[msg.ID]++
+	attempt := p.attempts[msg.ID]
+	p.mu.Unlock()
+
+	if attempt == 1 {
+		return true, 0, errors.New("first attempt fails")
+	}
+	envelope, err := messaging.Decode(msg.Body)
+	if err != nil {
+		return false, 0, err
+	}
+	p.processed <- envelope.Type
+	return false, 0, nil
+}
+
+// TestProducerToWorker publishes through the routing table of the all-in-one binary
+// and checks that a worker on the labor queue retries and then acks the check-out.
+func TestProducerToWorker(t *testing.T) {
+	broker := NewBroker()
+	producer := ports.NewProducer(broker, ports.DefaultRoutes(ports.Destinations{
+		Labor: "labor",
+		Email: "email",
+	}))
+
+	processor := &recordingProcessor{attempts: make(map[string]int), processed: make(chan string, 1)}
+	source := broker.Source("labor")
+	source.WaitTime = 10 * time.Millisecond
+	w := worker.NewWorker(source, processor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	events := []messaging.Event{
+		messaging.CheckOutEvent{WorkingTimeID: 1, EmployeeID: "emp-1"},
+		messaging.ComplianceViolationEvent{EmployeeID: "emp-1"},
+	}
+	for _, event := range events {
+		if err := producer.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish(%s) error = %v", event.EventType(), err)
+		}
+	}
+
+	select {
+	case eventType := <-processor.processed:
+		if eventType != messaging.TypeCheckedOut {
+			t.Errorf("processed %s, want %s", eventType, messaging.TypeCheckedOut)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("check-out was not processed")
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	for name, q := range broker.queues {
+		if name == "labor" {
+			continue
+		}
+		if len(q.entries) > 0 {
+			t.Errorf("queue %s holds %d messages nobody consumes", name, len(q.entries))
+		}
+	}
+}
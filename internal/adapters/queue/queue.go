@@ -0,0 +1,184 @@
+// Package queue selects the messaging backend configured by QUEUE_BACKEND and
+// builds the producer and message sources for it.
+package queue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	postgress "checkin.service/internal/adapters/Postgress"
+	snsadapter "checkin.service/internal/adapters/SNS"
+	sqsadapter "checkin.service/internal/adapters/SQS"
+	kafkaadapter "checkin.service/internal/adapters/kafka"
+	"checkin.service/internal/config"
+	"checkin.service/internal/ports"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+const (
+	BackendSQS      = "sqs"
+	BackendPostgres = "postgres"
+	BackendKafka    = "kafka"
+)
+
+// ErrNoDeadLetterQueue is returned for queues or backends without an inspectable
+// dead-letter queue.
+var ErrNoDeadLetterQueue = errors.New("no dead-letter queue")
+
+// Queue identifies one of the service's queues independently of the backend.
+// With the Postgres backend the value is the queue name in queue_jobs, with the
+// Kafka backend it is the topic.
+type Queue string
+
+const (
+	Labor      Queue = "labor"
+	Email      Queue = "email"
+	Compliance Queue = "compliance"
+	// Events is the integration queue of shift lifecycle events. Its name is
+	// configurable, see name.
+	Events Queue = "events"
+)
+
+// name returns the queue name (Postgres) or topic (Kafka) of q.
+func (q Queue) name(cfg config.Config) string {
+	if q == Events {
+		return cfg.EventsQueue
+	}
+	return string(q)
+}
+
+// sqsURLs returns the SQS queue and dead-letter queue URLs of q.
+func (q Queue) sqsURLs(cfg config.Config) (queueURL, dlqURL string) {
+	switch q {
+	case Labor:
+		return cfg.LaborSQSQueueURL, cfg.LaborDLQURL
+	case Email:
+		return cfg.EmailSQSQueueURL, cfg.EmailDLQURL
+	case Events:
+		return cfg.EventsSQSQueueURL, ""
+	default:
+		return cfg.ComplianceSQSQueueURL, ""
+	}
+}
+
+// Destination returns the address of q for the sender returned by NewSender: the
+// queue URL with the SQS backend, the queue name or topic otherwise.
+func (q Queue) Destination(cfg config.Config) string {
+	if cfg.QueueBackend == BackendSQS {
+		queueURL, _ := q.sqsURLs(cfg)
+		return queueURL
+	}
+	return q.name(cfg)
+}
+
+// NamedRoutes is the routing table for backends that address queues by name.
+// events is the name of the integration queue; empty disables it.
+func NamedRoutes(events string) ports.Routes {
+	return ports.DefaultRoutes(ports.Destinations{
+		Labor:      string(Labor),
+		Email:      string(Email),
+		Compliance: string(Compliance),
+		Events:     events,
+	})
+}
+
+// NewProducer creates the producer for the configured backend. awsCfg is only
+// used by the SQS backend and db only by the Postgres backend. With SNS_TOPIC_ARN
+// set, the SQS backend publishes every event once to that topic instead of
+// sending to each queue. Failures to send to the events queue are only logged.
+func NewProducer(cfg config.Config, db *sql.DB, awsCfg aws.Config) (*ports.Producer, error) {
+	switch cfg.QueueBackend {
+	case BackendSQS:
+		if cfg.SNSTopicARN != "" {
+			return snsadapter.NewSNSProducer(sns.NewFromConfig(awsCfg), cfg.SNSTopicARN), nil
+		}
+		routes := ports.DefaultRoutes(ports.Destinations{
+			Labor:      cfg.LaborSQSQueueURL,
+			Email:      cfg.EmailSQSQueueURL,
+			Compliance: cfg.ComplianceSQSQueueURL,
+			Events:     cfg.EventsSQSQueueURL,
+		})
+		producer := sqsadapter.NewSQSProducer(sqs.NewFromConfig(awsCfg), routes, sqsBatchLinger(cfg))
+		producer.BestEffort = []string{cfg.EventsSQSQueueURL}
+		return producer, nil
+	case BackendPostgres:
+		producer := ports.NewProducer(postgress.NewQueueSender(db), NamedRoutes(cfg.EventsQueue))
+		producer.BestEffort = []string{cfg.EventsQueue}
+		return producer, nil
+	case BackendKafka:
+		producer := kafkaadapter.NewKafkaProducer(kafkaBrokers(cfg), NamedRoutes(cfg.EventsQueue))
+		producer.BestEffort = []string{cfg.EventsQueue}
+		return producer, nil
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q", cfg.QueueBackend)
+	}
+}
+
+// NewSender creates a sender for the configured backend that sends to a single queue
+// given by Queue.Destination, bypassing the routing table and SNS.
+func NewSender(cfg config.Config, db *sql.DB, awsCfg aws.Config) (ports.MessageSender, error) {
+	switch cfg.QueueBackend {
+	case BackendSQS:
+		return sqsadapter.NewSQSSender(sqs.NewFromConfig(awsCfg), sqsBatchLinger(cfg)), nil
+	case BackendPostgres:
+		return postgress.NewQueueSender(db), nil
+	case BackendKafka:
+		return kafkaadapter.NewKafkaSender(kafkaBrokers(cfg)), nil
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q", cfg.QueueBackend)
+	}
+}
+
+// NewSource creates the message source of queue q for the configured backend. If the
+// source implements io.Closer it must be closed after the worker using it has stopped.
+func NewSource(cfg config.Config, db *sql.DB, awsCfg aws.Config, q Queue) (ports.MessageSource, error) {
+	switch cfg.QueueBackend {
+	case BackendSQS:
+		queueURL, dlqURL := q.sqsURLs(cfg)
+		source := sqsadapter.NewSQSSource(sqs.NewFromConfig(awsCfg), queueURL, sqsBatchLinger(cfg))
+		source.DeadLetterQueueURL = dlqURL
+		return source, nil
+	case BackendPostgres:
+		source := postgress.NewQueueSource(db, q.name(cfg))
+		source.VisibilityTimeout = time.Duration(cfg.VisibilityTimeoutSeconds) * time.Second
+		source.MaxReceiveCount = cfg.QueueMaxReceiveCount
+		return source, nil
+	case BackendKafka:
+		return kafkaadapter.NewKafkaSource(kafkaBrokers(cfg), cfg.KafkaConsumerGroup+"-"+string(q), q.name(cfg)), nil
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q", cfg.QueueBackend)
+	}
+}
+
+// NewDeadLetterQueue gives access to the dead-letter queue of q for the configured
+// backend. Kafka's dead-letter topics are not supported, since a topic cannot have
+// single records removed.
+func NewDeadLetterQueue(cfg config.Config, db *sql.DB, awsCfg aws.Config, q Queue) (ports.DeadLetterQueue, error) {
+	switch cfg.QueueBackend {
+	case BackendSQS:
+		queueURL, dlqURL := q.sqsURLs(cfg)
+		if dlqURL == "" {
+			return nil, fmt.Errorf("%w for queue %s", ErrNoDeadLetterQueue, q)
+		}
+		return sqsadapter.NewSQSDeadLetterQueue(sqs.NewFromConfig(awsCfg), dlqURL, queueURL), nil
+	case BackendPostgres:
+		return postgress.NewDeadLetterQueue(db, q.name(cfg)), nil
+	case BackendKafka:
+		return nil, fmt.Errorf("%w with the kafka backend", ErrNoDeadLetterQueue)
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q", cfg.QueueBackend)
+	}
+}
+
+func kafkaBrokers(cfg config.Config) []string {
+	return strings.Split(cfg.KafkaBrokers, ",")
+}
+
+func sqsBatchLinger(cfg config.Config) time.Duration {
+	return time.Duration(cfg.SQSBatchLingerMillis) * time.Millisecond
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+	"github.com/gorilla/mux"
+)
+
+// AbsenceHandler serves absence records imported from the HR system.
+type AbsenceHandler struct {
+	Service *checkin_service.AbsenceService
+}
+
+type AbsenceRequest struct {
+	EmployeeID    string  `json:"employeeId"`
+	Type          string  `json:"type"`
+	StartDate     string  `json:"startDate"`
+	EndDate       string  `json:"endDate"`
+	HoursCredited float64 `json:"hoursCredited"`
+	Status        string  `json:"status,omitempty"`
+}
+
+// RecordAbsence stores a single absence sent as JSON.
+func (h *AbsenceHandler) RecordAbsence(w http.ResponseWriter, r *http.Request) {
+	var req AbsenceRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	start, err := time.Parse(time.DateOnly, req.StartDate)
+	if err != nil {
+		http.Error(w, "Invalid startDate, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse(time.DateOnly, req.EndDate)
+	if err != nil {
+		http.Error(w, "Invalid endDate, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.Service.Record(r.Context(), &model.Absence{
+		EmployeeID:    req.EmployeeID,
+		Type:          model.AbsenceType(req.Type),
+		StartDate:     start,
+		EndDate:       end,
+		HoursCredited: req.HoursCredited,
+		Status:        model.AbsenceStatus(req.Status),
+	})
+	if errors.Is(err, checkin_service.ErrInvalidAbsence) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Service error storing absence", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, a)
+}
+
+// ImportAbsences imports absences from a CSV request body.
+func (h *AbsenceHandler) ImportAbsences(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Service.Import(r.Context(), r.Body)
+	if errors.Is(err, checkin_service.ErrInvalidAbsence) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Service error importing absences", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ListAbsences lists absences overlapping the from/to window (default the last 30
+// days), optionally for a single employee given in the path or the query.
+func (h *AbsenceHandler) ListAbsences(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+	if employeeID == "" {
+		employeeID = r.URL.Query().Get("employeeId")
+	}
+
+	from, to, err := parseTimeRange(r, 30*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.Service.List(r.Context(), employeeID, from, to)
+	if err != nil {
+		http.Error(w, "Service error loading absences", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"absences": list})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// ComplianceHandler exposes recorded working-time violations.
+type ComplianceHandler struct {
+	Service *checkin_service.ComplianceService
+}
+
+// ListViolations returns violations, by default those of the last 30 days. It accepts
+// optional employeeId, rule, from and to query parameters.
+func (h *ComplianceHandler) ListViolations(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r, 30*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	list, err := h.Service.ListViolations(r.Context(), q.Get("employeeId"), model.ComplianceRule(q.Get("rule")), from, to)
+	if err != nil {
+		http.Error(w, "Service error loading violations", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"violations": list})
+}
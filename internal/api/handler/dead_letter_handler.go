@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+	"github.com/gorilla/mux"
+)
+
+// DeadLetterHandler exposes the dead-letter queues to operators.
+type DeadLetterHandler struct {
+	Service *checkin_service.DeadLetterService
+}
+
+type RedriveRequest struct {
+	IDs        []string `json:"ids"`
+	All        bool     `json:"all"`
+	EmployeeID string   `json:"employeeId"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Error      string   `json:"error"`
+}
+
+// ListQueues returns the names of the dead-letter queues.
+func (h *DeadLetterHandler) ListQueues(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"queues": h.Service.Queues()})
+}
+
+// ListMessages returns the decoded messages of a dead-letter queue. It accepts
+// optional employeeId, from, to, error and limit (default 100, max 1000) query
+// parameters. truncated is set when the queue holds more messages than were scanned.
+func (h *DeadLetterHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := deadLetterFilter(q.Get("employeeId"), q.Get("from"), q.Get("to"), q.Get("error"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	queue := mux.Vars(r)["queue"]
+	list, truncated, err := h.Service.List(r.Context(), queue, filter, limit)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"queue": queue, "messages": list, "truncated": truncated})
+}
+
+// Redrive sends the selected messages of a dead-letter queue back to their queue.
+func (h *DeadLetterHandler) Redrive(w http.ResponseWriter, r *http.Request) {
+	var req RedriveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := deadLetterFilter(req.EmployeeID, req.From, req.To, req.Error)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.Redrive(r.Context(), mux.Vars(r)["queue"], req.IDs, req.All, filter)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// deadLetterFilter builds a filter from request values. from and to accept RFC3339
+// timestamps or plain dates and may be empty.
+func deadLetterFilter(employeeID, from, to, errorText string) (model.DeadLetterFilter, error) {
+	filter := model.DeadLetterFilter{EmployeeID: employeeID, Error: errorText}
+	if from != "" {
+		t, err := parseTime(from)
+		if err != nil {
+			return filter, errors.New("invalid 'from' parameter")
+		}
+		filter.From = t
+	}
+	if to != "" {
+		t, err := parseTime(to)
+		if err != nil {
+			return filter, errors.New("invalid 'to' parameter")
+		}
+		filter.To = t
+	}
+	return filter, nil
+}
+
+// writeDeadLetterError maps dead-letter errors to HTTP status codes.
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, checkin_service.ErrUnknownDeadLetterQueue):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, checkin_service.ErrRedriveSelection):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Service error processing dead-letter queue", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// DisputeHandler serves the supervisor review queue for shift disputes.
+type DisputeHandler struct {
+	Service *checkin_service.DisputeService
+}
+
+type ResolveDisputeRequest struct {
+	ReviewerID   string     `json:"reviewerId"`
+	Comment      string     `json:"comment,omitempty"`
+	ClockInTime  *time.Time `json:"clockInTime,omitempty"`
+	ClockOutTime *time.Time `json:"clockOutTime,omitempty"`
+}
+
+// ListDisputes returns disputes by status (default OPEN), i.e. the review queue.
+func (h *DisputeHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	status := model.DisputeStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = model.StatusDisputeOpen
+	}
+
+	list, err := h.Service.List(r.Context(), r.URL.Query().Get("employeeId"), status)
+	if err != nil {
+		http.Error(w, "Service error loading disputes", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"disputes": list})
+}
+
+// CorrectDispute resolves a dispute by correcting the shift's clock times.
+func (h *DisputeHandler) CorrectDispute(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeResolve(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.Service.Correct(r.Context(), id, req.ReviewerID, req.ClockInTime, req.ClockOutTime, req.Comment)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// RejectDispute resolves a dispute without changing the shift.
+func (h *DisputeHandler) RejectDispute(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeResolve(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.Service.Reject(r.Context(), id, req.ReviewerID, req.Comment)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+func decodeResolve(w http.ResponseWriter, r *http.Request) (int64, ResolveDisputeRequest, bool) {
+	var req ResolveDisputeRequest
+
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return 0, req, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return 0, req, false
+	}
+
+	return id, req, true
+}
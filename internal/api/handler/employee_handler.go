@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+	"github.com/gorilla/mux"
+)
+
+// EmployeeHandler serves the employee-scoped self-service API. Every route is
+// nested under /employees/{employeeId} and only sees that employee's data.
+type EmployeeHandler struct {
+	CheckIn  checkin_service.CheckInService
+	Disputes *checkin_service.DisputeService
+}
+
+type RaiseDisputeRequest struct {
+	Comment              string     `json:"comment"`
+	ProposedClockInTime  *time.Time `json:"proposedClockInTime,omitempty"`
+	ProposedClockOutTime *time.Time `json:"proposedClockOutTime,omitempty"`
+}
+
+// ListShifts returns the employee's shifts, by default those of the last 7 days.
+func (h *EmployeeHandler) ListShifts(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	from, to, err := parseTimeRange(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	shifts, err := h.CheckIn.ListShifts(r.Context(), employeeID, from, to)
+	if err != nil {
+		http.Error(w, "Service error loading shifts", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"employeeId": employeeID, "shifts": shifts})
+}
+
+// WeeklyHours returns the employee's running totals for the current week and the
+// weeks before it (?weeks=N, default 4, max 52).
+func (h *EmployeeHandler) WeeklyHours(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	weeks := 4
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 52 {
+			http.Error(w, "weeks must be between 1 and 52", http.StatusBadRequest)
+			return
+		}
+		weeks = n
+	}
+
+	totals, err := h.CheckIn.WeeklyHours(r.Context(), employeeID, weeks)
+	if err != nil {
+		http.Error(w, "Service error loading hours", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"employeeId": employeeID, "weeks": totals})
+}
+
+// RaiseDispute disputes one of the employee's shifts.
+func (h *EmployeeHandler) RaiseDispute(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+	workingTimeID, ok := pathID(w, r, "workingTimeId")
+	if !ok {
+		return
+	}
+
+	var req RaiseDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.Disputes.Raise(r.Context(), employeeID, workingTimeID, req.Comment, req.ProposedClockInTime, req.ProposedClockOutTime)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, d)
+}
+
+// ListDisputes returns the disputes the employee raised.
+func (h *EmployeeHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	list, err := h.Disputes.List(r.Context(), employeeID, model.DisputeStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		http.Error(w, "Service error loading disputes", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"employeeId": employeeID, "disputes": list})
+}
+
+// writeDisputeError maps dispute workflow errors to HTTP status codes.
+func writeDisputeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, checkin_service.ErrShiftNotFound),
+		errors.Is(err, checkin_service.ErrDisputeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, checkin_service.ErrDisputeResolved),
+		errors.Is(err, checkin_service.ErrShiftVoided):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, checkin_service.ErrDisputeCommentRequired),
+		errors.Is(err, checkin_service.ErrDisputeNoReviewer),
+		errors.Is(err, checkin_service.ErrDisputeInvalidTimes):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Service error processing dispute", http.StatusInternalServerError)
+	}
+}
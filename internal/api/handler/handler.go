@@ -2,7 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	checkin_service "checkin.service/internal/core/service"
 	"github.com/gorilla/mux"
@@ -14,6 +16,12 @@ type CheckInHandler struct {
 
 type CheckInOutRequest struct {
 	EmployeeID string `json:"employeeId"`
+	JobCode    string `json:"jobCode,omitempty"`
+}
+
+type SwitchJobRequest struct {
+	EmployeeID string `json:"employeeId"`
+	JobCode    string `json:"jobCode"`
 }
 
 func (h *CheckInHandler) CheckInOut(w http.ResponseWriter, r *http.Request) {
@@ -29,8 +37,12 @@ func (h *CheckInHandler) CheckInOut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Service.ProcessCheckInOut(r.Context(), req.EmployeeID)
+	err := h.Service.ProcessCheckInOut(r.Context(), req.EmployeeID, req.JobCode)
 
+	if errors.Is(err, checkin_service.ErrTapDuringAbsence) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Service error processing event", http.StatusInternalServerError)
 		return
@@ -40,6 +52,56 @@ func (h *CheckInHandler) CheckInOut(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]any{"message": "Check-in/out event recorded for asynchronous processing."})
 }
 
+// SwitchJob moves an employee onto another job / work order.
+func (h *CheckInHandler) SwitchJob(w http.ResponseWriter, r *http.Request) {
+	var req SwitchJobRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.EmployeeID == "" || req.JobCode == "" {
+		http.Error(w, "EmployeeID and JobCode are required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.Service.SwitchJob(r.Context(), req.EmployeeID, req.JobCode)
+	if errors.Is(err, checkin_service.ErrTapDuringAbsence) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Service error processing event", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Job switch recorded."})
+}
+
+// GetJobLabor reports minutes worked per employee and job. It accepts optional
+// employeeId, from and to query parameters.
+func (h *CheckInHandler) GetJobLabor(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Service.GetJobLabor(r.Context(), r.URL.Query().Get("employeeId"), from, to)
+	if err != nil {
+		http.Error(w, "Service error loading job labor", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from": from,
+		"to":   to,
+		"jobs": report,
+	})
+}
+
 // GetCheckIn retrieves the last check-in for a given employee from the URL path.
 func (h *CheckInHandler) GetCheckIn(w http.ResponseWriter, r *http.Request) {
 
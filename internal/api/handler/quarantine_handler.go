@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// QuarantineHandler exposes quarantined messages for review and replay.
+type QuarantineHandler struct {
+	Service *checkin_service.QuarantineService
+}
+
+// ListMessages returns quarantined messages, newest first. It accepts optional queue,
+// status and limit (default 50, max 500) query parameters.
+func (h *QuarantineHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	list, err := h.Service.List(r.Context(), q.Get("queue"), model.QuarantineStatus(q.Get("status")), limit)
+	if err != nil {
+		http.Error(w, "Service error loading quarantined messages", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"messages": list})
+}
+
+// GetMessage returns a single quarantined message.
+func (h *QuarantineHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	m, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		writeQuarantineError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+// Replay sends a quarantined message back to its queue.
+func (h *QuarantineHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	m, err := h.Service.Replay(r.Context(), id)
+	if err != nil {
+		writeQuarantineError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+// Discard closes a quarantined message without replaying it.
+func (h *QuarantineHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	m, err := h.Service.Discard(r.Context(), id)
+	if err != nil {
+		writeQuarantineError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+// writeQuarantineError maps quarantine errors to HTTP status codes.
+func writeQuarantineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, checkin_service.ErrQuarantineNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, checkin_service.ErrQuarantineResolved):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, checkin_service.ErrNoReplayDestination):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Service error processing quarantined message", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// ReconciliationHandler exposes the reconciler's report and metrics.
+type ReconciliationHandler struct {
+	Service *checkin_service.ReconciliationService
+}
+
+// Report returns the report of the latest reconciler run.
+func (h *ReconciliationHandler) Report(w http.ResponseWriter, r *http.Request) {
+	report := h.Service.LastReport()
+	if report == nil {
+		http.Error(w, "The reconciler has not run yet", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Run runs the reconciler now and returns its report.
+func (h *ReconciliationHandler) Run(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Service.Reconcile(r.Context())
+	if err != nil {
+		http.Error(w, "Service error running reconciliation", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Metrics writes the reconciler's metrics in the Prometheus text format.
+func (h *ReconciliationHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	stats := h.Service.Stats()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP reconciliation_runs_total Reconciler runs, by outcome.")
+	fmt.Fprintln(w, "# TYPE reconciliation_runs_total counter")
+	fmt.Fprintf(w, "reconciliation_runs_total{outcome=\"success\"} %d\n", stats.Runs-stats.FailedRuns)
+	fmt.Fprintf(w, "reconciliation_runs_total{outcome=\"failure\"} %d\n", stats.FailedRuns)
+
+	fmt.Fprintln(w, "# HELP reconciliation_republished_total Events of stuck records republished, by channel.")
+	fmt.Fprintln(w, "# TYPE reconciliation_republished_total counter")
+	fmt.Fprintf(w, "reconciliation_republished_total{channel=\"labor\"} %d\n", stats.RepublishedLabor)
+	fmt.Fprintf(w, "reconciliation_republished_total{channel=\"email\"} %d\n", stats.RepublishedEmail)
+
+	if stats.Last == nil {
+		return
+	}
+	fmt.Fprintln(w, "# HELP reconciliation_discrepancies Working times found by the latest run, by kind.")
+	fmt.Fprintln(w, "# TYPE reconciliation_discrepancies gauge")
+	for _, kind := range model.DiscrepancyKinds {
+		fmt.Fprintf(w, "reconciliation_discrepancies{kind=%q} %d\n", kind, stats.Last.Counts[kind])
+	}
+
+	fmt.Fprintln(w, "# HELP reconciliation_last_run_timestamp_seconds Time of the latest successful run.")
+	fmt.Fprintln(w, "# TYPE reconciliation_last_run_timestamp_seconds gauge")
+	fmt.Fprintf(w, "reconciliation_last_run_timestamp_seconds %d\n", stats.Last.RunAt.Unix())
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// ShiftHandler serves supervisor actions on a single shift.
+type ShiftHandler struct {
+	Service checkin_service.CheckInService
+}
+
+type VoidShiftRequest struct {
+	VoidedBy string `json:"voidedBy"`
+	Reason   string `json:"reason"`
+}
+
+// VoidShift voids a shift, e.g. one created by a mistaken tap.
+func (h *ShiftHandler) VoidShift(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "workingTimeId")
+	if !ok {
+		return
+	}
+
+	var req VoidShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wt, err := h.Service.VoidShift(r.Context(), id, req.VoidedBy, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, checkin_service.ErrShiftNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, checkin_service.ErrShiftVoided):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, checkin_service.ErrVoidNoActor),
+			errors.Is(err, checkin_service.ErrVoidReasonRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Service error voiding shift", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wt)
+}
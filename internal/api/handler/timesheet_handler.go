@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+type TimesheetHandler struct {
+	Service *checkin_service.TimesheetService
+}
+
+type OpenTimesheetRequest struct {
+	EmployeeID string `json:"employeeId"`
+	// Date is any day in the pay period (YYYY-MM-DD); defaults to today.
+	Date string `json:"date,omitempty"`
+}
+
+type ReviewTimesheetRequest struct {
+	ApproverID string `json:"approverId"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// OpenTimesheet returns the employee's timesheet for a pay period, opening it if needed.
+func (h *TimesheetHandler) OpenTimesheet(w http.ResponseWriter, r *http.Request) {
+	var req OpenTimesheetRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.EmployeeID == "" {
+		http.Error(w, "EmployeeID is required", http.StatusBadRequest)
+		return
+	}
+
+	date := time.Now().UTC()
+	if req.Date != "" {
+		d, err := time.Parse(time.DateOnly, req.Date)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = d
+	}
+
+	ts, err := h.Service.OpenTimesheet(r.Context(), req.EmployeeID, date)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+// ListTimesheets lists timesheets by status (default SUBMITTED), i.e. the supervisor review queue.
+func (h *TimesheetHandler) ListTimesheets(w http.ResponseWriter, r *http.Request) {
+	status := model.TimesheetStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = model.StatusTimesheetSubmitted
+	}
+
+	list, err := h.Service.ListTimesheets(r.Context(), status)
+	if err != nil {
+		http.Error(w, "Service error loading timesheets", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"timesheets": list})
+}
+
+// GetTimesheet returns a single timesheet with its entries.
+func (h *TimesheetHandler) GetTimesheet(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	ts, err := h.Service.GetTimesheet(r.Context(), id)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+// SubmitTimesheet hands a timesheet over for supervisor review.
+func (h *TimesheetHandler) SubmitTimesheet(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	ts, err := h.Service.Submit(r.Context(), id)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+// ApproveTimesheet records a supervisor's approval.
+func (h *TimesheetHandler) ApproveTimesheet(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	ts, err := h.Service.Approve(r.Context(), id, req.ApproverID)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+// PublishLabor publishes the held check-outs of an approved timesheet again.
+func (h *TimesheetHandler) PublishLabor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	ts, err := h.Service.PublishLabor(r.Context(), id)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+// RejectTimesheet sends a submitted timesheet back to the employee with a reason.
+func (h *TimesheetHandler) RejectTimesheet(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	ts, err := h.Service.Reject(r.Context(), id, req.ApproverID, req.Reason)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ts)
+}
+
+func decodeReview(w http.ResponseWriter, r *http.Request) (int64, ReviewTimesheetRequest, bool) {
+	var req ReviewTimesheetRequest
+
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return 0, req, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return 0, req, false
+	}
+
+	return id, req, true
+}
+
+// writeTimesheetError maps timesheet workflow errors to HTTP status codes.
+func writeTimesheetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, checkin_service.ErrTimesheetNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, checkin_service.ErrTimesheetTransition),
+		errors.Is(err, checkin_service.ErrTimesheetOpenShifts),
+		errors.Is(err, checkin_service.ErrTimesheetLaborNotHeld):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, checkin_service.ErrTimesheetNoApprover),
+		errors.Is(err, checkin_service.ErrTimesheetNoRejection):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Service error processing timesheet", http.StatusInternalServerError)
+	}
+}
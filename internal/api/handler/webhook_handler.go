@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+)
+
+// WebhookHandler manages the webhook subscriptions of external systems.
+type WebhookHandler struct {
+	Service *checkin_service.WebhookService
+}
+
+type WebhookRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret"`
+}
+
+// Subscribe registers a URL for the given event types.
+func (h *WebhookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.Service.Subscribe(r.Context(), &model.WebhookSubscription{
+		Name:       req.Name,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// ListSubscriptions returns all subscriptions.
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	list, err := h.Service.List(r.Context())
+	if err != nil {
+		http.Error(w, "Service error loading webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": list})
+}
+
+// GetSubscription returns a single subscription.
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	sub, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// Unsubscribe deactivates a subscription.
+func (h *WebhookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Unsubscribe(r.Context(), id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first. It accepts
+// optional status and limit (default 50, max 500) query parameters.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	status := model.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+	list, err := h.Service.Deliveries(r.Context(), id, status, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptionId": id, "deliveries": list})
+}
+
+// writeWebhookError maps webhook errors to HTTP status codes.
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, checkin_service.ErrWebhookNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, checkin_service.ErrInvalidWebhookURL),
+		errors.Is(err, checkin_service.ErrWebhookURLForbidden),
+		errors.Is(err, checkin_service.ErrInvalidWebhookEvents),
+		errors.Is(err, checkin_service.ErrWebhookSecretTooShort):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Service error processing webhook", http.StatusInternalServerError)
+	}
+}
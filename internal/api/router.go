@@ -9,19 +9,121 @@ import (
 	checkin_service "checkin.service/internal/core/service"
 )
 
+// Services groups the application services exposed over HTTP.
+type Services struct {
+	CheckIn    checkin_service.CheckInService
+	Timesheets *checkin_service.TimesheetService
+	Disputes   *checkin_service.DisputeService
+	Absences   *checkin_service.AbsenceService
+	Compliance *checkin_service.ComplianceService
+	Webhooks   *checkin_service.WebhookService
+	// DeadLetters backs the admin endpoints; see app.NewDeadLetterService.
+	DeadLetters *checkin_service.DeadLetterService
+	// Quarantine backs the admin endpoints; see app.NewQuarantineService.
+	Quarantine *checkin_service.QuarantineService
+	// Reconciliation is started by the binary that runs it; see ReconciliationService.Run.
+	Reconciliation *checkin_service.ReconciliationService
+	// AdminToken guards the supervisor and admin endpoints; see requireToken.
+	AdminToken string
+}
+
 // NewRouter sets up the gorilla/mux router and defines all API routes.
-func NewRouter(service checkin_service.CheckInService) *mux.Router {
+func NewRouter(services Services) *mux.Router {
 
 	checkInHandler := handler.CheckInHandler{
-		Service: service,
+		Service: services.CheckIn,
+	}
+	timesheetHandler := handler.TimesheetHandler{
+		Service: services.Timesheets,
+	}
+	employeeHandler := handler.EmployeeHandler{
+		CheckIn:  services.CheckIn,
+		Disputes: services.Disputes,
+	}
+	disputeHandler := handler.DisputeHandler{
+		Service: services.Disputes,
+	}
+	absenceHandler := handler.AbsenceHandler{
+		Service: services.Absences,
+	}
+	shiftHandler := handler.ShiftHandler{
+		Service: services.CheckIn,
+	}
+	webhookHandler := handler.WebhookHandler{
+		Service: services.Webhooks,
+	}
+	complianceHandler := handler.ComplianceHandler{
+		Service: services.Compliance,
+	}
+	deadLetterHandler := handler.DeadLetterHandler{
+		Service: services.DeadLetters,
+	}
+	quarantineHandler := handler.QuarantineHandler{
+		Service: services.Quarantine,
+	}
+	reconciliationHandler := handler.ReconciliationHandler{
+		Service: services.Reconciliation,
 	}
 
 	r := mux.NewRouter()
 
 	api := r.PathPrefix("/api/v1").Subrouter()
+	// Supervisor actions, webhook subscriptions and the admin endpoints require the admin token.
+	protected := api.NewRoute().Subrouter()
+	protected.Use(requireToken(services.AdminToken))
 
 	api.HandleFunc("/checkin-checkout", checkInHandler.CheckInOut).Methods(http.MethodPost)
 	api.HandleFunc("/checkin/{employeeId}", checkInHandler.GetCheckIn).Methods(http.MethodPost)
+	api.HandleFunc("/switch-job", checkInHandler.SwitchJob).Methods(http.MethodPost)
+	api.HandleFunc("/reports/job-labor", checkInHandler.GetJobLabor).Methods(http.MethodGet)
+
+	api.HandleFunc("/timesheets", timesheetHandler.OpenTimesheet).Methods(http.MethodPost)
+	api.HandleFunc("/timesheets", timesheetHandler.ListTimesheets).Methods(http.MethodGet)
+	api.HandleFunc("/timesheets/{id}", timesheetHandler.GetTimesheet).Methods(http.MethodGet)
+	api.HandleFunc("/timesheets/{id}/submit", timesheetHandler.SubmitTimesheet).Methods(http.MethodPost)
+	protected.HandleFunc("/timesheets/{id}/approve", timesheetHandler.ApproveTimesheet).Methods(http.MethodPost)
+	protected.HandleFunc("/timesheets/{id}/reject", timesheetHandler.RejectTimesheet).Methods(http.MethodPost)
+	protected.HandleFunc("/timesheets/{id}/publish-labor", timesheetHandler.PublishLabor).Methods(http.MethodPost)
+
+	employee := api.PathPrefix("/employees/{employeeId}").Subrouter()
+	employee.HandleFunc("/shifts", employeeHandler.ListShifts).Methods(http.MethodGet)
+	employee.HandleFunc("/hours/weekly", employeeHandler.WeeklyHours).Methods(http.MethodGet)
+	employee.HandleFunc("/shifts/{workingTimeId}/disputes", employeeHandler.RaiseDispute).Methods(http.MethodPost)
+	employee.HandleFunc("/disputes", employeeHandler.ListDisputes).Methods(http.MethodGet)
+	employee.HandleFunc("/absences", absenceHandler.ListAbsences).Methods(http.MethodGet)
+
+	protected.HandleFunc("/shifts/{workingTimeId}/void", shiftHandler.VoidShift).Methods(http.MethodPost)
+
+	api.HandleFunc("/disputes", disputeHandler.ListDisputes).Methods(http.MethodGet)
+	protected.HandleFunc("/disputes/{id}/correct", disputeHandler.CorrectDispute).Methods(http.MethodPost)
+	protected.HandleFunc("/disputes/{id}/reject", disputeHandler.RejectDispute).Methods(http.MethodPost)
+
+	protected.HandleFunc("/absences", absenceHandler.RecordAbsence).Methods(http.MethodPost)
+	api.HandleFunc("/absences", absenceHandler.ListAbsences).Methods(http.MethodGet)
+	protected.HandleFunc("/absences/import", absenceHandler.ImportAbsences).Methods(http.MethodPost)
+
+	api.HandleFunc("/compliance/violations", complianceHandler.ListViolations).Methods(http.MethodGet)
+
+	protected.HandleFunc("/webhooks", webhookHandler.Subscribe).Methods(http.MethodPost)
+	protected.HandleFunc("/webhooks", webhookHandler.ListSubscriptions).Methods(http.MethodGet)
+	protected.HandleFunc("/webhooks/{id}", webhookHandler.GetSubscription).Methods(http.MethodGet)
+	protected.HandleFunc("/webhooks/{id}", webhookHandler.Unsubscribe).Methods(http.MethodDelete)
+	protected.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries).Methods(http.MethodGet)
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/dlq", deadLetterHandler.ListQueues).Methods(http.MethodGet)
+	admin.HandleFunc("/dlq/{queue}/messages", deadLetterHandler.ListMessages).Methods(http.MethodGet)
+	admin.HandleFunc("/dlq/{queue}/redrive", deadLetterHandler.Redrive).Methods(http.MethodPost)
+	admin.HandleFunc("/quarantine", quarantineHandler.ListMessages).Methods(http.MethodGet)
+	admin.HandleFunc("/quarantine/{id}", quarantineHandler.GetMessage).Methods(http.MethodGet)
+	admin.HandleFunc("/quarantine/{id}/replay", quarantineHandler.Replay).Methods(http.MethodPost)
+	admin.HandleFunc("/quarantine/{id}/discard", quarantineHandler.Discard).Methods(http.MethodPost)
+	admin.HandleFunc("/reconciliation", reconciliationHandler.Report).Methods(http.MethodGet)
+	admin.HandleFunc("/reconciliation/run", reconciliationHandler.Run).Methods(http.MethodPost)
+
+	// The metrics show the reconciler's findings, so they need the admin token like its report.
+	r.Handle("/metrics", requireToken(services.AdminToken)(http.HandlerFunc(reconciliationHandler.Metrics))).Methods(http.MethodGet)
+
 	api.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Service is operational."))
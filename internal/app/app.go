@@ -0,0 +1,124 @@
+// Package app wires the repositories and core services behind the REST API, so the
+// API binary and the all-in-one binary assemble them the same way.
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	postgress "checkin.service/internal/adapters/Postgress"
+	"checkin.service/internal/adapters/queue"
+	"checkin.service/internal/api"
+	"checkin.service/internal/config"
+	"checkin.service/internal/core/model"
+	checkin_service "checkin.service/internal/core/service"
+	"checkin.service/internal/ports"
+	"checkin.service/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewServices creates the services of the REST API on top of db, publishing through producer.
+func NewServices(cfg config.Config, db *sql.DB, producer *ports.Producer) (api.Services, error) {
+	repo := postgress.NewWorkingTimeRepository(db)
+	coreService := checkin_service.NewCheckInService(repo, *producer)
+
+	payPeriodAnchor, err := time.Parse(time.DateOnly, cfg.PayPeriodAnchor)
+	if err != nil {
+		return api.Services{}, fmt.Errorf("invalid PAY_PERIOD_ANCHOR: %w", err)
+	}
+	calendar := model.PayPeriodCalendar{Anchor: payPeriodAnchor, Days: cfg.PayPeriodDays}
+	timesheetRepo := postgress.NewTimesheetRepository(db)
+	if cfg.HoldLaborUntilApproved {
+		coreService.HoldLaborUntilApproved(timesheetRepo, calendar)
+	}
+	absenceRepo := postgress.NewAbsenceRepository(db)
+	coreService.UseAbsences(absenceRepo, checkin_service.AbsenceTapPolicy(cfg.AbsenceTapPolicy))
+	timesheetService := checkin_service.NewTimesheetService(repo, timesheetRepo, absenceRepo, *producer, calendar, cfg.HoldLaborUntilApproved)
+	absenceService := checkin_service.NewAbsenceService(absenceRepo)
+	complianceService := checkin_service.NewComplianceService(repo, postgress.NewComplianceRepository(db), *producer, model.ComplianceLimits{
+		MinRestHours:   cfg.MinRestHours,
+		MaxWeeklyHours: cfg.MaxWeeklyHours,
+		MaxShiftHours:  cfg.MaxShiftHours,
+	})
+	coreService.UseCompliance(complianceService)
+
+	disputeService := checkin_service.NewDisputeService(repo, postgress.NewDisputeRepository(db), coreService)
+
+	reconciliationService := checkin_service.NewReconciliationService(coreService, postgress.NewReconciliationRepository(db))
+	reconciliationService.PendingSLA = time.Duration(cfg.ReconcilePendingSLASeconds) * time.Second
+
+	webhookService := checkin_service.NewWebhookService(postgress.NewWebhookRepository(db))
+	webhookService.AllowPrivateNetworks = cfg.WebhookAllowPrivateNetworks
+
+	return api.Services{
+		CheckIn:    *coreService,
+		Timesheets: timesheetService,
+		Disputes:   disputeService,
+		Absences:   absenceService,
+		Compliance: complianceService,
+		Webhooks:   webhookService,
+		// Without dead-letter queues; see NewDeadLetterService.
+		DeadLetters: checkin_service.NewDeadLetterService(repo, nil),
+		// Without replay; see NewQuarantineService.
+		Quarantine:     checkin_service.NewQuarantineService(postgress.NewQuarantineRepository(db), nil, nil),
+		Reconciliation: reconciliationService,
+		AdminToken:     cfg.AdminToken,
+	}, nil
+}
+
+// NewQuarantineService creates the quarantine service, replaying messages through
+// sender, a sender from queue.NewSender, to the queues the workers consume.
+func NewQuarantineService(cfg config.Config, db *sql.DB, sender ports.MessageSender) *checkin_service.QuarantineService {
+	destinations := make(map[string]string)
+	for _, q := range []queue.Queue{queue.Labor, queue.Email, queue.Events} {
+		destinations[string(q)] = q.Destination(cfg)
+	}
+	return checkin_service.NewQuarantineService(postgress.NewQuarantineRepository(db), sender, destinations)
+}
+
+// NewDeadLetterService creates the dead-letter service for the labor and email queues
+// of the configured backend. awsCfg is only used by the SQS backend. Queues whose
+// dead-letter queue cannot be inspected are left out.
+func NewDeadLetterService(cfg config.Config, db *sql.DB, awsCfg aws.Config) (*checkin_service.DeadLetterService, error) {
+	queues := make(map[string]ports.DeadLetterQueue)
+	for name, q := range map[string]queue.Queue{
+		checkin_service.DeadLetterQueueLabor: queue.Labor,
+		checkin_service.DeadLetterQueueEmail: queue.Email,
+	} {
+		dlq, err := queue.NewDeadLetterQueue(cfg, db, awsCfg, q)
+		if errors.Is(err, queue.ErrNoDeadLetterQueue) {
+			log.Warn().Err(err).Msg("Dead-letter queue cannot be inspected")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		queues[name] = dlq
+	}
+
+	service := checkin_service.NewDeadLetterService(postgress.NewWorkingTimeRepository(db), queues)
+	service.RedriveRate = cfg.DLQRedriveRate
+	return service, nil
+}
+
+// NewHandler creates the router for services, wrapped with the logging and tracing middleware.
+func NewHandler(services api.Services) http.Handler {
+	router := api.NewRouter(services)
+
+	// Middleware to inject logger with trace ID
+	loggerMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			ctx = logger.EnrichContextWithLogger(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	// Wrap the router with OpenTelemetry middleware to create spans for each request
+	return otelhttp.NewHandler(loggerMiddleware(router), "api")
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
@@ -21,6 +24,96 @@ type Config struct {
 	AWSEndpoint      string `mapstructure:"AWS_ENDPOINT"`
 	LegacyAPIURL     string `mapstructure:"LEGACY_API_URL"`
 	IsLocalDev       bool   `mapstructure:"IS_LOCAL_DEV"`
+	// AdminToken is the bearer token required by supervisor and admin endpoints,
+	// such as voiding a shift. Empty disables those endpoints.
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	// QueueBackend selects the messaging backend: sqs, postgres or kafka.
+	QueueBackend string `mapstructure:"QUEUE_BACKEND"`
+	// QueueMaxReceiveCount is how often the Postgres backend delivers a job before
+	// moving it to queue_dead_letters, like an SQS maxReceiveCount. 0 disables the limit.
+	QueueMaxReceiveCount int `mapstructure:"QUEUE_MAX_RECEIVE_COUNT"`
+	// KafkaBrokers is a comma-separated list of host:port. Each worker consumes as
+	// the consumer group <KafkaConsumerGroup>-<queue>.
+	KafkaBrokers       string `mapstructure:"KAFKA_BROKERS"`
+	KafkaConsumerGroup string `mapstructure:"KAFKA_CONSUMER_GROUP"`
+
+	// ComplianceSQSQueueURL receives supervisor notifications about working-time violations.
+	ComplianceSQSQueueURL string `mapstructure:"COMPLIANCE_SQS_QUEUE_URL"`
+	// Shift lifecycle events for downstream systems go to EventsSQSQueueURL, or to the
+	// EventsQueue queue / topic with the Postgres and Kafka backends. Empty disables them.
+	EventsSQSQueueURL string `mapstructure:"EVENTS_SQS_QUEUE_URL"`
+	EventsQueue       string `mapstructure:"EVENTS_QUEUE"`
+	// SQSBatchLingerMillis is how long an SQS send, delete or visibility change waits
+	// for others to share a batch request with. 0 disables batching.
+	SQSBatchLingerMillis int `mapstructure:"SQS_BATCH_LINGER_MS"`
+	// SNSTopicARN, if set, is the topic the SQS backend publishes all events to. The
+	// queues are subscribed to it with filter policies on the event type.
+	SNSTopicARN string `mapstructure:"SNS_TOPIC_ARN"`
+
+	// Dead-letter queues and retry budgets of the labor and email workers.
+	LaborDLQURL      string `mapstructure:"LABOR_DLQ_URL"`
+	EmailDLQURL      string `mapstructure:"EMAIL_DLQ_URL"`
+	LaborMaxAttempts int    `mapstructure:"LABOR_MAX_ATTEMPTS"`
+	EmailMaxAttempts int    `mapstructure:"EMAIL_MAX_ATTEMPTS"`
+	// Failed legacy API calls and email sends are retried after a random delay of up
+	// to base * 2^(attempt-1) seconds, capped at max.
+	LaborRetryBaseSeconds int `mapstructure:"LABOR_RETRY_BASE_SECONDS"`
+	LaborRetryMaxSeconds  int `mapstructure:"LABOR_RETRY_MAX_SECONDS"`
+	EmailRetryBaseSeconds int `mapstructure:"EMAIL_RETRY_BASE_SECONDS"`
+	EmailRetryMaxSeconds  int `mapstructure:"EMAIL_RETRY_MAX_SECONDS"`
+	// Labor records whose retries ran out are parked in the retry schedule and sent
+	// again after up to LaborParkBaseSeconds * 2^(n-1) seconds, capped at
+	// LaborParkMaxSeconds, until they were parked LaborParkMaxAttempts times. 0 disables
+	// parking; enabling it requires a labor queue that keeps an employee's order. The
+	// scheduler re-enqueues at most RetrySchedulerRate jobs per second.
+	LaborParkMaxAttempts int `mapstructure:"LABOR_PARK_MAX_ATTEMPTS"`
+	LaborParkBaseSeconds int `mapstructure:"LABOR_PARK_BASE_SECONDS"`
+	LaborParkMaxSeconds  int `mapstructure:"LABOR_PARK_MAX_SECONDS"`
+	RetrySchedulerRate   int `mapstructure:"RETRY_SCHEDULER_RATE"`
+	// ProcessingLeaseSeconds is how long a worker owns a record it is processing.
+	ProcessingLeaseSeconds int `mapstructure:"PROCESSING_LEASE_SECONDS"`
+	// While a message is processed its visibility is extended to VisibilityTimeoutSeconds
+	// every HeartbeatIntervalSeconds.
+	VisibilityTimeoutSeconds int `mapstructure:"VISIBILITY_TIMEOUT_SECONDS"`
+	HeartbeatIntervalSeconds int `mapstructure:"HEARTBEAT_INTERVAL_SECONDS"`
+	// Workers scale between WorkerMinConcurrency and WorkerMaxConcurrency concurrent
+	// messages and back off when the mean processing time exceeds WorkerLatencyTargetMillis.
+	WorkerMinConcurrency      int `mapstructure:"WORKER_MIN_CONCURRENCY"`
+	WorkerMaxConcurrency      int `mapstructure:"WORKER_MAX_CONCURRENCY"`
+	WorkerLatencyTargetMillis int `mapstructure:"WORKER_LATENCY_TARGET_MS"`
+	// On SIGTERM workers wait up to ShutdownTimeoutSeconds for in-flight messages.
+	ShutdownTimeoutSeconds int `mapstructure:"SHUTDOWN_TIMEOUT_SECONDS"`
+
+	// DLQRedriveRate is how many dead-lettered messages per second a redrive sends back.
+	DLQRedriveRate int `mapstructure:"DLQ_REDRIVE_RATE"`
+
+	// The reconciler runs every ReconcileIntervalSeconds (0 disables it) and republishes
+	// channels still PENDING ReconcilePendingSLASeconds after check-out.
+	ReconcileIntervalSeconds   int `mapstructure:"RECONCILE_INTERVAL_SECONDS"`
+	ReconcilePendingSLASeconds int `mapstructure:"RECONCILE_PENDING_SLA_SECONDS"`
+
+	// Webhook deliveries are tried WebhookMaxAttempts times; each call times out
+	// after WebhookTimeoutSeconds.
+	WebhookMaxAttempts    int `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookTimeoutSeconds int `mapstructure:"WEBHOOK_TIMEOUT_SECONDS"`
+	// WebhookAllowPrivateNetworks lets subscriptions point at loopback and private
+	// addresses, e.g. for local testing. Off, such URLs are rejected and never called.
+	WebhookAllowPrivateNetworks bool `mapstructure:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+
+	// Pay periods are PayPeriodDays long, counted from PayPeriodAnchor (YYYY-MM-DD).
+	PayPeriodAnchor string `mapstructure:"PAY_PERIOD_ANCHOR"`
+	PayPeriodDays   int    `mapstructure:"PAY_PERIOD_DAYS"`
+	// HoldLaborUntilApproved delays publishing check-outs to the labor queue
+	// until the employee's timesheet for the pay period is approved.
+	HoldLaborUntilApproved bool `mapstructure:"HOLD_LABOR_UNTIL_APPROVED"`
+	// AbsenceTapPolicy is what happens to a tap during an approved absence: ignore, flag or block.
+	AbsenceTapPolicy string `mapstructure:"ABSENCE_TAP_POLICY"`
+
+	// Working-time compliance limits.
+	MinRestHours   float64 `mapstructure:"COMPLIANCE_MIN_REST_HOURS"`
+	MaxWeeklyHours float64 `mapstructure:"COMPLIANCE_MAX_WEEKLY_HOURS"`
+	MaxShiftHours  float64 `mapstructure:"COMPLIANCE_MAX_SHIFT_HOURS"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -34,13 +127,88 @@ func LoadConfig() (config Config, err error) {
 	viper.SetDefault("AWS_REGION", "us-east-1") // Default region for AWS services
 	viper.SetDefault("LABOR_SQS_QUEUE_URL", "http://localstack:4566/000000000000/labor-queue")
 	viper.SetDefault("EMAIL_SQS_QUEUE_URL", "http://localstack:4566/000000000000/email-queue")
+	viper.SetDefault("QUEUE_BACKEND", "sqs")
+	viper.SetDefault("QUEUE_MAX_RECEIVE_COUNT", 5)
+	viper.SetDefault("KAFKA_BROKERS", "kafka:9092")
+	viper.SetDefault("KAFKA_CONSUMER_GROUP", "checkin-service")
+	viper.SetDefault("COMPLIANCE_SQS_QUEUE_URL", "http://localstack:4566/000000000000/compliance-queue")
+	viper.SetDefault("EVENTS_SQS_QUEUE_URL", "http://localstack:4566/000000000000/events-queue")
+	viper.SetDefault("EVENTS_QUEUE", "events")
+	viper.SetDefault("SNS_TOPIC_ARN", "")
+	viper.SetDefault("SQS_BATCH_LINGER_MS", 10)
+	viper.SetDefault("LABOR_DLQ_URL", "http://localstack:4566/000000000000/labor-queue-dlq")
+	viper.SetDefault("EMAIL_DLQ_URL", "http://localstack:4566/000000000000/email-queue-dlq")
+	viper.SetDefault("LABOR_MAX_ATTEMPTS", 5) // Matches the queues' maxReceiveCount
+	viper.SetDefault("EMAIL_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LABOR_RETRY_BASE_SECONDS", 20)
+	viper.SetDefault("LABOR_RETRY_MAX_SECONDS", 3600)
+	viper.SetDefault("EMAIL_RETRY_BASE_SECONDS", 20)
+	viper.SetDefault("EMAIL_RETRY_MAX_SECONDS", 3600)
+	viper.SetDefault("LABOR_PARK_MAX_ATTEMPTS", 0)
+	viper.SetDefault("LABOR_PARK_BASE_SECONDS", 900)
+	viper.SetDefault("LABOR_PARK_MAX_SECONDS", 21600)
+	viper.SetDefault("RETRY_SCHEDULER_RATE", 5)
+	viper.SetDefault("PROCESSING_LEASE_SECONDS", 120)
+	viper.SetDefault("VISIBILITY_TIMEOUT_SECONDS", 30)
+	viper.SetDefault("HEARTBEAT_INTERVAL_SECONDS", 10)
+	viper.SetDefault("SHUTDOWN_TIMEOUT_SECONDS", 30)
+	viper.SetDefault("WORKER_MIN_CONCURRENCY", 1)
+	viper.SetDefault("WORKER_MAX_CONCURRENCY", 10)
+	viper.SetDefault("WORKER_LATENCY_TARGET_MS", 5000)
+	viper.SetDefault("DLQ_REDRIVE_RATE", 10)
+	viper.SetDefault("RECONCILE_INTERVAL_SECONDS", 300)
+	viper.SetDefault("RECONCILE_PENDING_SLA_SECONDS", 14400)
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_TIMEOUT_SECONDS", 10)
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
 	viper.SetDefault("AWS_ENDPOINT", "http://localstack:4566")
 	viper.SetDefault("LEGACY_API_URL", "http://localhost:8081/")
 	viper.SetDefault("IS_LOCAL_DEV", true)
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("PAY_PERIOD_ANCHOR", "2024-01-01") // A Monday, so periods run Monday to Sunday
+	viper.SetDefault("PAY_PERIOD_DAYS", 7)
+	viper.SetDefault("HOLD_LABOR_UNTIL_APPROVED", false)
+	viper.SetDefault("ABSENCE_TAP_POLICY", "flag")
+	viper.SetDefault("COMPLIANCE_MIN_REST_HOURS", 11)
+	viper.SetDefault("COMPLIANCE_MAX_WEEKLY_HOURS", 48)
+	viper.SetDefault("COMPLIANCE_MAX_SHIFT_HOURS", 10)
 
 	// Read in environment variables that match the keys.
 	viper.AutomaticEnv()
 
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	config.clamp()
+	err = config.validate()
 	return
 }
+
+// clamp raises settings that have a lower bound to it. A minimum concurrency below
+// one would let a worker scale down to no concurrency at all and stop receiving.
+func (c *Config) clamp() {
+	c.WorkerMinConcurrency = max(c.WorkerMinConcurrency, 1)
+	c.WorkerMaxConcurrency = max(c.WorkerMaxConcurrency, c.WorkerMinConcurrency)
+}
+
+// validate rejects settings that would otherwise silently fall back to another behavior.
+func (c Config) validate() error {
+	switch c.AbsenceTapPolicy {
+	case "ignore", "flag", "block":
+	default:
+		return fmt.Errorf("invalid ABSENCE_TAP_POLICY %q: must be ignore, flag or block", c.AbsenceTapPolicy)
+	}
+
+	// The scheduler sends an employee's parked jobs one after the other; a queue that
+	// reorders them would let a later record reach the legacy system first. Kafka
+	// keeps the order on the topic, but its retries go through <topic>.retry and
+	// overtake the employee's later records.
+	orderedLabor := c.QueueBackend == "sqs" && strings.HasSuffix(c.LaborSQSQueueURL, ".fifo")
+	if c.LaborParkMaxAttempts > 0 && !orderedLabor {
+		return fmt.Errorf("LABOR_PARK_MAX_ATTEMPTS requires an ordered labor queue: QUEUE_BACKEND=sqs with a .fifo LABOR_SQS_QUEUE_URL")
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestValidateLaborParking(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  string
+		laborURL string
+		attempts int
+		wantErr  bool
+	}{
+		{name: "parking off", backend: "postgres", wantErr: false},
+		{name: "sqs fifo", backend: "sqs", laborURL: "https://sqs.eu-west-1.amazonaws.com/123/labor-queue.fifo", attempts: 30, wantErr: false},
+		{name: "sqs standard", backend: "sqs", laborURL: "https://sqs.eu-west-1.amazonaws.com/123/labor-queue", attempts: 30, wantErr: true},
+		{name: "kafka retries overtake", backend: "kafka", attempts: 30, wantErr: true},
+		{name: "postgres", backend: "postgres", attempts: 30, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{AbsenceTapPolicy: "flag", QueueBackend: tt.backend, LaborSQSQueueURL: tt.laborURL, LaborParkMaxAttempts: tt.attempts}
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+)
+
+// AbsenceType defines the kind of paid or unpaid time off.
+type AbsenceType string
+
+const (
+	AbsenceSick          AbsenceType = "SICK"
+	AbsenceVacation      AbsenceType = "VACATION"
+	AbsencePublicHoliday AbsenceType = "PUBLIC_HOLIDAY"
+	AbsenceUnpaid        AbsenceType = "UNPAID"
+	AbsenceOther         AbsenceType = "OTHER"
+)
+
+// Valid reports whether t is a known absence type.
+func (t AbsenceType) Valid() bool {
+	switch t {
+	case AbsenceSick, AbsenceVacation, AbsencePublicHoliday, AbsenceUnpaid, AbsenceOther:
+		return true
+	}
+	return false
+}
+
+// AbsenceStatus defines whether an absence is in effect.
+type AbsenceStatus string
+
+const (
+	StatusAbsenceApproved  AbsenceStatus = "APPROVED"
+	StatusAbsencePending   AbsenceStatus = "PENDING"
+	StatusAbsenceCancelled AbsenceStatus = "CANCELLED"
+)
+
+// Absence is a sick day, vacation or other time off, imported from the HR system.
+// StartDate and EndDate are inclusive days; HoursCredited covers the whole range.
+type Absence struct {
+	ID            int64         `json:"id"`
+	EmployeeID    string        `json:"employeeId"`
+	Type          AbsenceType   `json:"type"`
+	StartDate     time.Time     `json:"startDate"`
+	EndDate       time.Time     `json:"endDate"`
+	HoursCredited float64       `json:"hoursCredited"`
+	Status        AbsenceStatus `json:"status"`
+}
+
+// Days returns the number of calendar days the absence covers.
+func (a Absence) Days() int {
+	return int(a.EndDate.Sub(a.StartDate).Hours()/24) + 1
+}
+
+// HoursIn returns the credited hours that fall into [from, to), spreading the
+// credit evenly over the absence's days.
+func (a Absence) HoursIn(from, to time.Time) float64 {
+	days := a.Days()
+	if days <= 0 {
+		return 0
+	}
+
+	overlap := 0
+	for d := a.StartDate; !d.After(a.EndDate); d = d.AddDate(0, 0, 1) {
+		if !d.Before(from) && d.Before(to) {
+			overlap++
+		}
+	}
+
+	return a.HoursCredited * float64(overlap) / float64(days)
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// ComplianceRule identifies a working-time regulation.
+type ComplianceRule string
+
+const (
+	RuleInsufficientRest  ComplianceRule = "INSUFFICIENT_REST"
+	RuleWeeklyCapExceeded ComplianceRule = "WEEKLY_CAP_EXCEEDED"
+	RuleShiftTooLong      ComplianceRule = "SHIFT_TOO_LONG"
+)
+
+// ComplianceViolation records a breach of a working-time rule, e.g. a check-in
+// less than the minimum rest after the previous check-out.
+type ComplianceViolation struct {
+	ID            int64          `json:"id"`
+	EmployeeID    string         `json:"employeeId"`
+	WorkingTimeID int64          `json:"workingTimeId"`
+	Rule          ComplianceRule `json:"rule"`
+	MeasuredHours float64        `json:"measuredHours"`
+	LimitHours    float64        `json:"limitHours"`
+	Detail        string         `json:"detail"`
+	OccurredAt    time.Time      `json:"occurredAt"`
+}
+
+// ComplianceLimits are the thresholds the compliance rules are checked against.
+type ComplianceLimits struct {
+	MinRestHours   float64
+	MaxWeeklyHours float64
+	MaxShiftHours  float64
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetterMessage is a message in a dead-letter queue, decoded for triage.
+type DeadLetterMessage struct {
+	ID            string `json:"id"`
+	Queue         string `json:"queue"`
+	EventType     string `json:"eventType,omitempty"`
+	EmployeeID    string `json:"employeeId,omitempty"`
+	WorkingTimeID int64  `json:"workingTimeId,omitempty"`
+	// Reason and FailedAt are empty for messages the broker dead-lettered itself.
+	Reason       string          `json:"reason,omitempty"`
+	FailedAt     *time.Time      `json:"failedAt,omitempty"`
+	ReceiveCount int             `json:"receiveCount,omitempty"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+// DeadLetterFilter selects dead-letter messages. Empty fields match everything.
+type DeadLetterFilter struct {
+	EmployeeID string
+	// From and To bound the failure time; messages without one never match a bound.
+	From, To time.Time
+	// Error is matched case-insensitively against the failure reason.
+	Error string
+}
+
+// RedriveResult is the outcome of a redrive.
+type RedriveResult struct {
+	Queue    string   `json:"queue"`
+	Redriven []string `json:"redriven"`
+	// NotFound are requested IDs that were not in the dead-letter queue or did not
+	// match the filter.
+	NotFound []string `json:"notFound,omitempty"`
+	// Parked are redriven IDs whose labor record is PARKED in the retry schedule. Their
+	// retries were not reset and the schedule sends them again as well.
+	Parked []string `json:"parked,omitempty"`
+	// Truncated is set when the dead-letter queue holds more messages than a redrive
+	// looks at; run it again for the rest.
+	Truncated bool `json:"truncated,omitempty"`
+}
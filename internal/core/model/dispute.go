@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// DisputeStatus defines where an employee's dispute is in the supervisor review queue.
+type DisputeStatus string
+
+const (
+	StatusDisputeOpen      DisputeStatus = "OPEN"
+	StatusDisputeCorrected DisputeStatus = "CORRECTED"
+	StatusDisputeRejected  DisputeStatus = "REJECTED"
+)
+
+// Dispute is raised by an employee against one of their WorkingTime records.
+// A supervisor resolves it with either a correction or a rejection.
+type Dispute struct {
+	ID                   int64         `json:"id"`
+	WorkingTimeID        int64         `json:"workingTimeId"`
+	EmployeeID           string        `json:"employeeId"`
+	Comment              string        `json:"comment"`
+	ProposedClockInTime  *time.Time    `json:"proposedClockInTime,omitempty"`
+	ProposedClockOutTime *time.Time    `json:"proposedClockOutTime,omitempty"`
+	Status               DisputeStatus `json:"status"`
+	ReviewedBy           string        `json:"reviewedBy,omitempty"`
+	ReviewComment        string        `json:"reviewComment,omitempty"`
+	ReviewedAt           *time.Time    `json:"reviewedAt,omitempty"`
+	CreatedAt            time.Time     `json:"createdAt"`
+}
+
+// WeeklyHours is an employee's running total for one week (Monday to Sunday, UTC).
+// Hours covers worked time only; AbsenceHours is the credited time off.
+type WeeklyHours struct {
+	WeekStart    time.Time `json:"weekStart"`
+	Hours        float64   `json:"hours"`
+	AbsenceHours float64   `json:"absenceHours"`
+	Shifts       int       `json:"shifts"`
+	OpenShifts   int       `json:"openShifts"`
+}
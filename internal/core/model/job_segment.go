@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// JobSegment is the part of a shift an employee spent on a single job / work order.
+// A shift is split into segments every time the employee taps "switch job" at a workstation.
+type JobSegment struct {
+	ID            int64      `json:"id"`
+	WorkingTimeID int64      `json:"workingTimeId"`
+	EmployeeID    string     `json:"employeeId"`
+	JobCode       string     `json:"jobCode"`
+	StartTime     time.Time  `json:"startTime"`
+	EndTime       *time.Time `json:"endTime,omitempty"`
+	MinutesWorked float64    `json:"minutesWorked,omitempty"`
+}
+
+// JobLabor is the total time an employee spent on a job within a reporting window.
+type JobLabor struct {
+	EmployeeID    string  `json:"employeeId"`
+	JobCode       string  `json:"jobCode"`
+	MinutesWorked float64 `json:"minutesWorked"`
+}
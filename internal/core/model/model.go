@@ -12,6 +12,9 @@ const (
 	StatusWorkingProcessing WorkingTimeStatus = "PROCESSING"
 	StatusWorkingCompleted  WorkingTimeStatus = "COMPLETED"
 	StatusWorkingFailed     WorkingTimeStatus = "FAILED"
+	// StatusWorkingParked is a record waiting in the retry schedule for the legacy
+	// system to recover.
+	StatusWorkingParked WorkingTimeStatus = "PARKED"
 )
 
 // EmailStatus defines the state of the email event processing.
@@ -35,4 +38,12 @@ type WorkingTime struct {
 	EmailStatus     EmailStatus       `json:"emailStatus"`
 	LaborRetryCount int               `json:"laborRetryCount"`
 	EmailRetryCount int               `json:"emailRetryCount"`
+	// FlaggedAbsenceID is set when the shift was tapped during an approved absence.
+	FlaggedAbsenceID *int64 `json:"flaggedAbsenceId,omitempty"`
+	// Version starts at 1 and is incremented whenever the shift is corrected.
+	Version int `json:"version"`
+	// VoidedAt is set when the shift was voided; a voided shift no longer counts.
+	VoidedAt   *time.Time `json:"voidedAt,omitempty"`
+	VoidedBy   string     `json:"voidedBy,omitempty"`
+	VoidReason string     `json:"voidReason,omitempty"`
 }
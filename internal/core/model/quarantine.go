@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// QuarantineStatus defines the review state of a quarantined message.
+type QuarantineStatus string
+
+const (
+	StatusQuarantined         QuarantineStatus = "QUARANTINED"
+	StatusQuarantineReplayed  QuarantineStatus = "REPLAYED"
+	StatusQuarantineDiscarded QuarantineStatus = "DISCARDED"
+)
+
+// QuarantinedMessage is a message a processor could not handle and that would fail
+// the same way on every delivery, such as a malformed body. The worker stores it and
+// removes it from the queue; after a fix it can be replayed to its queue.
+type QuarantinedMessage struct {
+	ID    int64  `json:"id"`
+	Queue string `json:"queue"`
+	// Processor is the Go type of the processor that rejected the message.
+	Processor    string            `json:"processor"`
+	MessageID    string            `json:"messageId"`
+	EmployeeID   string            `json:"employeeId,omitempty"`
+	Body         string            `json:"body"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error"`
+	ReceiveCount int               `json:"receiveCount,omitempty"`
+	Status       QuarantineStatus  `json:"status"`
+	// QuarantinedAt is updated when the same message is quarantined again.
+	QuarantinedAt time.Time  `json:"quarantinedAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+)
+
+// DiscrepancyKind defines what is wrong with a working time found by the reconciler.
+type DiscrepancyKind string
+
+const (
+	// The channel has been PENDING, or PROCESSING under an expired lease, for longer
+	// than the SLA after check-out; its message was probably lost.
+	DiscrepancyLaborStuck DiscrepancyKind = "LABOR_STUCK"
+	DiscrepancyEmailStuck DiscrepancyKind = "EMAIL_STUCK"
+	// One channel is COMPLETED and the other FAILED; the failed message is in its DLQ.
+	DiscrepancyLaborFailed DiscrepancyKind = "LABOR_FAILED"
+	DiscrepancyEmailFailed DiscrepancyKind = "EMAIL_FAILED"
+	// The labor channel is PARKED in the retry schedule, waiting for the legacy system.
+	DiscrepancyLaborParked DiscrepancyKind = "LABOR_PARKED"
+)
+
+// DiscrepancyKinds lists every kind, so reports and metrics show kinds without findings.
+var DiscrepancyKinds = []DiscrepancyKind{
+	DiscrepancyLaborStuck,
+	DiscrepancyEmailStuck,
+	DiscrepancyLaborFailed,
+	DiscrepancyEmailFailed,
+	DiscrepancyLaborParked,
+}
+
+// Discrepancy is a working time whose processing status needs attention.
+type Discrepancy struct {
+	Kind          DiscrepancyKind   `json:"kind"`
+	WorkingTimeID int64             `json:"workingTimeId"`
+	EmployeeID    string            `json:"employeeId"`
+	LaborStatus   WorkingTimeStatus `json:"laborStatus"`
+	EmailStatus   EmailStatus       `json:"emailStatus"`
+	ClockOutTime  time.Time         `json:"clockOutTime"`
+	// LastRepublishedAt is when the reconciler last republished the stuck channel.
+	LastRepublishedAt *time.Time `json:"lastRepublishedAt,omitempty"`
+	// Republished is set if this run republished the channel's event.
+	Republished bool `json:"republished"`
+}
+
+// ReconciliationReport is the outcome of one reconciler run.
+type ReconciliationReport struct {
+	RunAt         time.Time               `json:"runAt"`
+	Counts        map[DiscrepancyKind]int `json:"counts"`
+	Republished   int                     `json:"republished"`
+	Discrepancies []Discrepancy           `json:"discrepancies"`
+}
+
+// ReconciliationStats are the reconciler's totals since the process started, and its
+// latest report.
+type ReconciliationStats struct {
+	Runs             int64
+	FailedRuns       int64
+	RepublishedLabor int64
+	RepublishedEmail int64
+	Last             *ReconciliationReport
+}
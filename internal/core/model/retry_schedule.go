@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ScheduledRetry is a queue message whose retries ran out during an outage. It is
+// parked in the retry schedule and sent to its queue again at NextAttemptAt.
+type ScheduledRetry struct {
+	ID            int64
+	Queue         string
+	WorkingTimeID int64
+	EmployeeID    string
+	Body          []byte
+	Attributes    map[string]string
+	// Attempts is how many times the message has been parked.
+	Attempts      int
+	NextAttemptAt time.Time
+	ParkedAt      time.Time
+	LastError     string
+}
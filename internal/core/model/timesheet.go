@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+)
+
+// TimesheetStatus defines where a timesheet is in the supervisor approval workflow.
+type TimesheetStatus string
+
+const (
+	StatusTimesheetOpen      TimesheetStatus = "OPEN"
+	StatusTimesheetSubmitted TimesheetStatus = "SUBMITTED"
+	StatusTimesheetApproved  TimesheetStatus = "APPROVED"
+	StatusTimesheetRejected  TimesheetStatus = "REJECTED"
+)
+
+// Timesheet aggregates an employee's working times and absences over one pay period.
+// TotalHours is WorkedHours plus AbsenceHours. PeriodEnd is exclusive.
+type Timesheet struct {
+	ID              int64           `json:"id"`
+	EmployeeID      string          `json:"employeeId"`
+	PeriodStart     time.Time       `json:"periodStart"`
+	PeriodEnd       time.Time       `json:"periodEnd"`
+	Status          TimesheetStatus `json:"status"`
+	TotalHours      float64         `json:"totalHours"`
+	WorkedHours     float64         `json:"workedHours"`
+	AbsenceHours    float64         `json:"absenceHours"`
+	SubmittedAt     *time.Time      `json:"submittedAt,omitempty"`
+	ReviewedBy      string          `json:"reviewedBy,omitempty"`
+	ReviewedAt      *time.Time      `json:"reviewedAt,omitempty"`
+	RejectionReason string          `json:"rejectionReason,omitempty"`
+	Entries         []WorkingTime   `json:"entries"`
+	Absences        []Absence       `json:"absences"`
+	// UnpublishedLabor lists the working times whose held check-out could not be
+	// published to the labor queue on approval.
+	UnpublishedLabor []int64 `json:"unpublishedLabor,omitempty"`
+}
+
+// PayPeriodCalendar splits time into consecutive pay periods of Days length,
+// counted from Anchor.
+type PayPeriodCalendar struct {
+	Anchor time.Time
+	Days   int
+}
+
+// PeriodFor returns the [start, end) pay period containing t.
+func (c PayPeriodCalendar) PeriodFor(t time.Time) (time.Time, time.Time) {
+	days := c.Days
+	if days <= 0 {
+		days = 7
+	}
+	anchor := time.Date(c.Anchor.Year(), c.Anchor.Month(), c.Anchor.Day(), 0, 0, 0, 0, time.UTC)
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	offset := int(day.Sub(anchor).Hours()/24) % days
+	if offset < 0 {
+		offset += days
+	}
+
+	start := day.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, days)
+}
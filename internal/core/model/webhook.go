@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// WebhookDeliveryStatus defines the state of a webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	StatusDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	StatusDeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	StatusDeliveryFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookSubscription is an external system that receives shift events over HTTP,
+// e.g. contractor management or the canteen system.
+type WebhookSubscription struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	// Secret signs every delivery; it is never returned by the API.
+	Secret    string    `json:"-"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery is one event sent to one subscription, with the outcome of its
+// latest attempt. The deliveries of a subscription are its delivery log.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscriptionId"`
+	EventID        string                `json:"eventId"`
+	EventType      string                `json:"eventType"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`
+	LastStatusCode int                   `json:"lastStatusCode,omitempty"`
+	LastError      string                `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+
+	// Set when a delivery is claimed for sending.
+	Payload []byte `json:"-"`
+	URL     string `json:"-"`
+	Secret  string `json:"-"`
+}
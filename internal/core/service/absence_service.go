@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+var ErrInvalidAbsence = errors.New("invalid absence")
+
+// AbsenceImportResult summarises a CSV import. Rows with errors are skipped;
+// the others are imported.
+type AbsenceImportResult struct {
+	Imported int                  `json:"imported"`
+	Errors   []AbsenceImportError `json:"errors,omitempty"`
+}
+
+// AbsenceImportError describes a rejected CSV row.
+type AbsenceImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// absenceCSVColumns are the columns an import must have; "status" is optional
+// and defaults to PENDING.
+var absenceCSVColumns = []string{"employee_id", "type", "start_date", "end_date", "hours_credited"}
+
+// AbsenceService records sick days, vacation and other time off so they can be
+// merged into timesheets and reports.
+type AbsenceService struct {
+	absences repository.AbsenceRepository
+}
+
+// NewAbsenceService creates the absence service.
+func NewAbsenceService(absences repository.AbsenceRepository) *AbsenceService {
+	return &AbsenceService{absences: absences}
+}
+
+// Record validates and stores a single absence.
+func (s *AbsenceService) Record(ctx context.Context, a *model.Absence) (*model.Absence, error) {
+	if err := validateAbsence(a); err != nil {
+		return nil, err
+	}
+
+	id, err := s.absences.UpsertAbsence(ctx, a)
+	if err != nil {
+		return nil, errors.New("failed to store absence")
+	}
+
+	a.ID = id
+	return a, nil
+}
+
+// List returns absences overlapping [from, to); an empty employeeID lists everyone.
+func (s *AbsenceService) List(ctx context.Context, employeeID string, from, to time.Time) ([]model.Absence, error) {
+	return s.absences.ListAbsences(ctx, employeeID, from, to)
+}
+
+// Import reads absences from CSV with a header row naming the columns
+// employee_id, type, start_date, end_date, hours_credited and optionally status.
+// Dates are YYYY-MM-DD and inclusive.
+func (s *AbsenceService) Import(ctx context.Context, r io.Reader) (*AbsenceImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing CSV header", ErrInvalidAbsence)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range absenceCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing CSV column %q", ErrInvalidAbsence, name)
+		}
+	}
+
+	result := &AbsenceImportResult{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, AbsenceImportError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		a, err := parseAbsenceRecord(record, columns)
+		if err == nil {
+			_, err = s.Record(ctx, a)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, AbsenceImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+func parseAbsenceRecord(record []string, columns map[string]int) (*model.Absence, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	start, err := time.Parse(time.DateOnly, field("start_date"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidAbsence)
+	}
+	end, err := time.Parse(time.DateOnly, field("end_date"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidAbsence)
+	}
+	hours, err := strconv.ParseFloat(field("hours_credited"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: hours_credited must be a number", ErrInvalidAbsence)
+	}
+
+	return &model.Absence{
+		EmployeeID:    field("employee_id"),
+		Type:          model.AbsenceType(strings.ToUpper(field("type"))),
+		StartDate:     start,
+		EndDate:       end,
+		HoursCredited: hours,
+		Status:        model.AbsenceStatus(strings.ToUpper(field("status"))),
+	}, nil
+}
+
+func validateAbsence(a *model.Absence) error {
+	// Only absences approved on purpose are merged into timesheets and hours.
+	if a.Status == "" {
+		a.Status = model.StatusAbsencePending
+	}
+
+	switch {
+	case a.EmployeeID == "":
+		return fmt.Errorf("%w: employee_id is required", ErrInvalidAbsence)
+	case !a.Type.Valid():
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAbsence, a.Type)
+	case a.EndDate.Before(a.StartDate):
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidAbsence)
+	case a.HoursCredited < 0:
+		return fmt.Errorf("%w: hours_credited must not be negative", ErrInvalidAbsence)
+	}
+
+	switch a.Status {
+	case model.StatusAbsenceApproved, model.StatusAbsencePending, model.StatusAbsenceCancelled:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown status %q", ErrInvalidAbsence, a.Status)
+}
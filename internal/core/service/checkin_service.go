@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -9,11 +10,40 @@ import (
 	"checkin.service/internal/ports"
 	"checkin.service/internal/ports/messaging"
 	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// AbsenceTapPolicy defines what happens to a tap during an approved absence.
+type AbsenceTapPolicy string
+
+const (
+	AbsenceTapIgnore AbsenceTapPolicy = "ignore"
+	AbsenceTapFlag   AbsenceTapPolicy = "flag"
+	AbsenceTapBlock  AbsenceTapPolicy = "block"
+)
+
+var ErrTapDuringAbsence = errors.New("employee has an approved absence today")
+
+var (
+	ErrShiftVoided        = errors.New("shift is already voided")
+	ErrVoidReasonRequired = errors.New("a reason is required to void a shift")
+	ErrVoidNoActor        = errors.New("voidedBy is required")
 )
 
 type CheckInService struct {
 	repo     repository.Repository
 	producer ports.Producer
+
+	// Set by HoldLaborUntilApproved.
+	timesheets repository.TimesheetRepository
+	calendar   model.PayPeriodCalendar
+
+	// Set by UseAbsences.
+	absences      repository.AbsenceRepository
+	absencePolicy AbsenceTapPolicy
+
+	// Set by UseCompliance.
+	compliance *ComplianceService
 }
 
 // NewCheckInService creates a new instance of our main application service,
@@ -25,9 +55,30 @@ func NewCheckInService(repo repository.Repository, p ports.Producer) *CheckInSer
 	}
 }
 
+// HoldLaborUntilApproved stops check-outs from being published to the labor queue
+// until the employee's timesheet for that pay period is approved. Check-outs that
+// land in an already approved period are published right away.
+func (s *CheckInService) HoldLaborUntilApproved(timesheets repository.TimesheetRepository, calendar model.PayPeriodCalendar) {
+	s.timesheets = timesheets
+	s.calendar = calendar
+}
+
+// UseAbsences merges credited absence hours into the weekly totals and applies
+// policy to taps made on a day with an approved absence.
+func (s *CheckInService) UseAbsences(absences repository.AbsenceRepository, policy AbsenceTapPolicy) {
+	s.absences = absences
+	s.absencePolicy = policy
+}
+
+// UseCompliance evaluates the working-time compliance rules on every check-in and check-out.
+func (s *CheckInService) UseCompliance(compliance *ComplianceService) {
+	s.compliance = compliance
+}
+
 // ProcessCheckInOut is the core business logic. It figures out if an employee
-// is clocking in or out by checking for an open work record.
-func (s *CheckInService) ProcessCheckInOut(ctx context.Context, employeeID string) error {
+// is clocking in or out by checking for an open work record. The optional jobCode
+// starts the first job segment of a new shift.
+func (s *CheckInService) ProcessCheckInOut(ctx context.Context, employeeID, jobCode string) error {
 	currentTime := time.Now().UTC()
 
 	openWorkTime, err := s.repo.FindLastCheckIn(ctx, employeeID)
@@ -35,24 +86,189 @@ func (s *CheckInService) ProcessCheckInOut(ctx context.Context, employeeID strin
 		return errors.New("failed to query last check-in")
 	}
 
+	absence, err := s.tapAbsence(ctx, employeeID, currentTime, openWorkTime == nil)
+	if err != nil {
+		return err
+	}
+
 	if openWorkTime == nil {
-		return s.handleCheckIn(ctx, employeeID, currentTime)
+		id, err := s.handleCheckIn(ctx, employeeID, jobCode, currentTime)
+		if err != nil {
+			return err
+		}
+		return s.flagAbsence(ctx, id, absence)
+	}
+
+	if err := s.flagAbsence(ctx, openWorkTime.ID, absence); err != nil {
+		return err
 	}
 
 	return s.handleCheckOut(ctx, openWorkTime, currentTime)
 }
 
+// SwitchJob handles a "switch job" tap from a workstation reader. It closes the
+// job segment the employee is currently on and opens a new one for jobCode.
+// If the employee has no open shift, the tap checks them in on that job.
+func (s *CheckInService) SwitchJob(ctx context.Context, employeeID, jobCode string) error {
+	currentTime := time.Now().UTC()
+
+	openWorkTime, err := s.repo.FindLastCheckIn(ctx, employeeID)
+	if err != nil {
+		return errors.New("failed to query last check-in")
+	}
+
+	absence, err := s.tapAbsence(ctx, employeeID, currentTime, openWorkTime == nil)
+	if err != nil {
+		return err
+	}
+
+	if openWorkTime == nil {
+		id, err := s.handleCheckIn(ctx, employeeID, jobCode, currentTime)
+		if err != nil {
+			return err
+		}
+		return s.flagAbsence(ctx, id, absence)
+	}
+
+	if err := s.flagAbsence(ctx, openWorkTime.ID, absence); err != nil {
+		return err
+	}
+
+	if err := s.repo.CloseJobSegment(ctx, openWorkTime.ID, currentTime); err != nil {
+		return errors.New("failed to close current job segment")
+	}
+
+	if _, err := s.repo.StartJobSegment(ctx, openWorkTime.ID, employeeID, jobCode, currentTime); err != nil {
+		return errors.New("failed to start job segment")
+	}
+
+	return nil
+}
+
+// GetJobLabor reports the minutes spent per employee and job in [from, to).
+func (s *CheckInService) GetJobLabor(ctx context.Context, employeeID string, from, to time.Time) ([]model.JobLabor, error) {
+	return s.repo.GetJobLabor(ctx, employeeID, from, to)
+}
+
 // UpdateWorkingTimeStatus is a simple pass-through to the repository layer,
 // mainly used by background workers to update the status of a job.
 func (s *CheckInService) UpdateWorkingTimeStatus(ctx context.Context, id int64, status model.WorkingTimeStatus, retryCount int) error {
 	return s.repo.UpdateLaborStatus(ctx, id, status, retryCount)
 }
 
+// ListShifts returns an employee's shifts that started in [from, to).
+func (s *CheckInService) ListShifts(ctx context.Context, employeeID string, from, to time.Time) ([]model.WorkingTime, error) {
+	return s.repo.ListWorkingTimes(ctx, employeeID, from, to)
+}
+
+// WeeklyHours returns the running hour totals of the last weeks (Monday to Sunday,
+// UTC), oldest first. A shift that is still open counts up to now.
+func (s *CheckInService) WeeklyHours(ctx context.Context, employeeID string, weeks int) ([]model.WeeklyHours, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	currentWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	from := currentWeek.AddDate(0, 0, -7*(weeks-1))
+
+	shifts, err := s.repo.ListWorkingTimes(ctx, employeeID, from, currentWeek.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, errors.New("failed to load shifts")
+	}
+
+	totals := make([]model.WeeklyHours, weeks)
+	for i := range totals {
+		totals[i].WeekStart = from.AddDate(0, 0, 7*i)
+	}
+
+	if s.absences != nil {
+		absences, err := s.absences.ListAbsences(ctx, employeeID, from, currentWeek.AddDate(0, 0, 7))
+		if err != nil {
+			return nil, errors.New("failed to load absences")
+		}
+		for _, a := range absences {
+			if a.Status != model.StatusAbsenceApproved {
+				continue
+			}
+			for i := range totals {
+				totals[i].AbsenceHours += a.HoursIn(totals[i].WeekStart, totals[i].WeekStart.AddDate(0, 0, 7))
+			}
+		}
+	}
+
+	for _, shift := range shifts {
+		week := &totals[int(shift.ClockInTime.Sub(from).Hours()/24)/7]
+		week.Shifts++
+		if shift.ClockOutTime == nil {
+			week.OpenShifts++
+			week.Hours += now.Sub(shift.ClockInTime).Hours()
+			continue
+		}
+		week.Hours += shift.HoursWorked
+	}
+
+	return totals, nil
+}
+
 // handleCheckIn handles the clock-in workflow.
-func (s *CheckInService) handleCheckIn(ctx context.Context, employeeID string, clockIn time.Time) error {
-	_, err := s.repo.CreateCheckIn(ctx, employeeID, clockIn)
+func (s *CheckInService) handleCheckIn(ctx context.Context, employeeID, jobCode string, clockIn time.Time) (int64, error) {
+	id, err := s.repo.CreateCheckIn(ctx, employeeID, clockIn)
 	if err != nil {
-		return errors.New("failed to create check-in record")
+		return 0, errors.New("failed to create check-in record")
+	}
+
+	if jobCode != "" {
+		if _, err := s.repo.StartJobSegment(ctx, id, employeeID, jobCode, clockIn); err != nil {
+			return 0, errors.New("failed to start job segment")
+		}
+	}
+
+	event := messaging.CheckedInEvent{
+		WorkingTimeID: id,
+		EmployeeID:    employeeID,
+		ClockInTime:   clockIn,
+		JobCode:       jobCode,
+	}
+	if err := s.producer.Publish(ctx, event); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("employee_id", employeeID).Msg("Failed to publish check-in event")
+	}
+
+	if s.compliance != nil {
+		// A violation never blocks the tap itself.
+		if err := s.compliance.CheckIn(ctx, employeeID, id, clockIn); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("employee_id", employeeID).Msg("Compliance check on check-in failed")
+		}
+	}
+
+	return id, nil
+}
+
+// tapAbsence returns the approved absence the employee has on the day of a tap, if
+// taps are checked against absences. Under the block policy such a tap is refused
+// if it is a check-in; a shift that is already open can always be closed.
+func (s *CheckInService) tapAbsence(ctx context.Context, employeeID string, at time.Time, checkIn bool) (*model.Absence, error) {
+	if s.absences == nil || s.absencePolicy == AbsenceTapIgnore {
+		return nil, nil
+	}
+
+	absence, err := s.absences.FindApprovedAbsence(ctx, employeeID, at)
+	if err != nil {
+		return nil, errors.New("failed to query absences")
+	}
+
+	if absence != nil && checkIn && s.absencePolicy == AbsenceTapBlock {
+		return nil, ErrTapDuringAbsence
+	}
+
+	return absence, nil
+}
+
+// flagAbsence marks a shift as tapped during the given absence, if any.
+func (s *CheckInService) flagAbsence(ctx context.Context, workingTimeID int64, absence *model.Absence) error {
+	if absence == nil {
+		return nil
+	}
+
+	if err := s.repo.FlagWorkingTime(ctx, workingTimeID, absence.ID); err != nil {
+		return errors.New("failed to flag shift")
 	}
 
 	return nil
@@ -63,27 +279,52 @@ func (s *CheckInService) handleCheckOut(ctx context.Context, workTime *model.Wor
 	duration := clockOut.Sub(workTime.ClockInTime)
 	hoursWorked := duration.Hours()
 
+	// Closes the open job segment too; events are only published once both are stored.
 	err := s.repo.UpdateCheckOut(ctx, workTime.ID, clockOut, hoursWorked, workTime.EmployeeID)
 	if err != nil {
 		return errors.New("failed to update check-out record")
 	}
+	workTime.ClockOutTime = &clockOut
+	workTime.HoursWorked = hoursWorked
+
+	if s.compliance != nil {
+		if err := s.compliance.CheckOut(ctx, workTime); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("employee_id", workTime.EmployeeID).Msg("Compliance check on check-out failed")
+		}
+	}
 
 	emailEvent := messaging.EmailEvent{
 		WorkingTimeID: workTime.ID,
 		EmployeeID:    workTime.EmployeeID,
 		HoursWorked:   hoursWorked,
 		OccurredAt:    time.Now(),
+		Version:       workTime.Version,
+	}
+	if err := s.producer.Publish(ctx, emailEvent); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("employee_id", workTime.EmployeeID).Msg("Failed to publish check-out summary")
 	}
-	s.producer.PublishEmail(ctx, emailEvent)
 
-	checkInOutEvent := messaging.CheckOutEvent{
-		WorkingTimeID: workTime.ID,
-		EmployeeID:    workTime.EmployeeID,
-		HoursWorked:   hoursWorked,
-		ClockOutTime:  clockOut,
+	return s.publishLabor(ctx, workTime)
+}
+
+// publishLabor sends a finished shift to the labor queue, unless it is held back
+// until its timesheet is approved.
+func (s *CheckInService) publishLabor(ctx context.Context, workTime *model.WorkingTime) error {
+	held, err := s.holdsLabor(ctx, workTime)
+	if err != nil {
+		return err
+	}
+	if held {
+		// Published by the timesheet approval instead.
+		return nil
+	}
+
+	checkInOutEvent, err := checkOutEvent(ctx, s.repo, workTime)
+	if err != nil {
+		return err
 	}
 
-	err = s.producer.PublishLabor(ctx, checkInOutEvent)
+	err = s.producer.Publish(ctx, checkInOutEvent)
 
 	if err != nil {
 		return errors.New("failed to publish check-out event to queue")
@@ -91,3 +332,131 @@ func (s *CheckInService) handleCheckOut(ctx context.Context, workTime *model.Wor
 
 	return nil
 }
+
+// publishCorrection announces a corrected shift. Like a check-out, it only reaches
+// the labor queue once the timesheet is approved; a held correction is published
+// with the rest of the period by the approval.
+func (s *CheckInService) publishCorrection(ctx context.Context, workTime *model.WorkingTime, previousIn, previousOut time.Time, disputeID int64, correctedBy string) error {
+	held, err := s.holdsLabor(ctx, workTime)
+	if err != nil {
+		return err
+	}
+	if held {
+		return nil
+	}
+
+	corrected, err := checkOutEvent(ctx, s.repo, workTime)
+	if err != nil {
+		return err
+	}
+
+	event := messaging.ShiftCorrectedEvent{
+		CheckOutEvent:        corrected,
+		ClockInTime:          workTime.ClockInTime,
+		PreviousClockInTime:  previousIn,
+		PreviousClockOutTime: previousOut,
+		DisputeID:            disputeID,
+		CorrectedBy:          correctedBy,
+	}
+	if err := s.producer.Publish(ctx, event); err != nil {
+		return errors.New("failed to publish correction event to queue")
+	}
+
+	return nil
+}
+
+// VoidShift marks a shift as void, e.g. a tap made by mistake. A voided shift no
+// longer counts towards hours and is not sent to the labor system. One that was
+// already sent is not reversed there: ShiftVoided only goes to the events queue.
+func (s *CheckInService) VoidShift(ctx context.Context, id int64, voidedBy, reason string) (*model.WorkingTime, error) {
+	if voidedBy == "" {
+		return nil, ErrVoidNoActor
+	}
+	if reason == "" {
+		return nil, ErrVoidReasonRequired
+	}
+
+	wt, err := s.repo.GetCheckInOut(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load shift")
+	}
+	if wt.VoidedAt != nil {
+		return nil, ErrShiftVoided
+	}
+
+	now := time.Now().UTC()
+	ok, err := s.repo.VoidWorkingTime(ctx, id, voidedBy, reason, now)
+	if err != nil {
+		return nil, errors.New("failed to void shift")
+	}
+	if !ok {
+		return nil, ErrShiftVoided
+	}
+	wt.VoidedAt = &now
+	wt.VoidedBy = voidedBy
+	wt.VoidReason = reason
+	wt.Version++
+
+	event := messaging.ShiftVoidedEvent{
+		WorkingTimeID: wt.ID,
+		EmployeeID:    wt.EmployeeID,
+		VoidedBy:      voidedBy,
+		Reason:        reason,
+		VoidedAt:      now,
+		Version:       wt.Version,
+	}
+	if err := s.producer.Publish(ctx, event); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int64("working_time_id", wt.ID).Msg("Failed to publish void event")
+	}
+
+	return wt, nil
+}
+
+// holdsLabor reports whether a check-out must wait for its timesheet to be approved
+// before it is published to the labor queue.
+func (s *CheckInService) holdsLabor(ctx context.Context, workTime *model.WorkingTime) (bool, error) {
+	if s.timesheets == nil {
+		return false, nil
+	}
+
+	periodStart, _ := s.calendar.PeriodFor(workTime.ClockInTime)
+	ts, err := s.timesheets.FindTimesheet(ctx, workTime.EmployeeID, periodStart)
+	if err != nil {
+		return false, errors.New("failed to query timesheet")
+	}
+
+	return ts == nil || ts.Status != model.StatusTimesheetApproved, nil
+}
+
+// checkOutEvent builds the labor queue event for a finished shift. The time spent
+// per job is merged by job code into line items for the legacy system.
+func checkOutEvent(ctx context.Context, repo repository.Repository, workTime *model.WorkingTime) (messaging.CheckOutEvent, error) {
+	segments, err := repo.ListJobSegments(ctx, workTime.ID)
+	if err != nil {
+		return messaging.CheckOutEvent{}, errors.New("failed to load job segments")
+	}
+
+	var lines []messaging.JobLine
+	index := make(map[string]int)
+	for _, seg := range segments {
+		i, ok := index[seg.JobCode]
+		if !ok {
+			i = len(lines)
+			index[seg.JobCode] = i
+			lines = append(lines, messaging.JobLine{JobCode: seg.JobCode})
+		}
+		lines[i].MinutesWorked += seg.MinutesWorked
+	}
+
+	return messaging.CheckOutEvent{
+		WorkingTimeID: workTime.ID,
+		EmployeeID:    workTime.EmployeeID,
+		HoursWorked:   workTime.HoursWorked,
+		ClockOutTime:  *workTime.ClockOutTime,
+		JobLines:      lines,
+		Version:       workTime.Version,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// ComplianceService checks shifts against working-time regulations (minimum rest
+// between shifts, maximum shift length, weekly hour cap). Violations are stored
+// and supervisors are notified through the compliance queue.
+type ComplianceService struct {
+	repo       repository.Repository
+	violations repository.ComplianceRepository
+	producer   ports.Producer
+	limits     model.ComplianceLimits
+}
+
+// NewComplianceService creates the compliance checker. A zero limit disables its rule.
+func NewComplianceService(repo repository.Repository, violations repository.ComplianceRepository, p ports.Producer, limits model.ComplianceLimits) *ComplianceService {
+	return &ComplianceService{
+		repo:       repo,
+		violations: violations,
+		producer:   p,
+		limits:     limits,
+	}
+}
+
+// CheckIn evaluates the rules that apply when a shift starts: the rest since the
+// previous check-out.
+func (s *ComplianceService) CheckIn(ctx context.Context, employeeID string, workingTimeID int64, clockIn time.Time) error {
+	if s.limits.MinRestHours <= 0 {
+		return nil
+	}
+
+	last, err := s.repo.FindLastCheckOut(ctx, employeeID, clockIn)
+	if err != nil {
+		return errors.New("failed to query previous shift")
+	}
+	if last == nil {
+		return nil
+	}
+
+	rest := clockIn.Sub(*last.ClockOutTime).Hours()
+	if rest >= s.limits.MinRestHours {
+		return nil
+	}
+
+	return s.record(ctx, &model.ComplianceViolation{
+		EmployeeID:    employeeID,
+		WorkingTimeID: workingTimeID,
+		Rule:          model.RuleInsufficientRest,
+		MeasuredHours: rest,
+		LimitHours:    s.limits.MinRestHours,
+		Detail:        fmt.Sprintf("%.2f hours of rest since the shift ending %s", rest, last.ClockOutTime.Format(time.RFC3339)),
+		OccurredAt:    clockIn,
+	})
+}
+
+// CheckOut evaluates the rules that apply when a shift ends: its length and the
+// hours worked in its week (Monday to Sunday, UTC). The weekly cap is reported
+// once per week.
+func (s *ComplianceService) CheckOut(ctx context.Context, workTime *model.WorkingTime) error {
+	clockOut := *workTime.ClockOutTime
+
+	if s.limits.MaxShiftHours > 0 && workTime.HoursWorked > s.limits.MaxShiftHours {
+		err := s.record(ctx, &model.ComplianceViolation{
+			EmployeeID:    workTime.EmployeeID,
+			WorkingTimeID: workTime.ID,
+			Rule:          model.RuleShiftTooLong,
+			MeasuredHours: workTime.HoursWorked,
+			LimitHours:    s.limits.MaxShiftHours,
+			Detail:        fmt.Sprintf("shift of %.2f hours", workTime.HoursWorked),
+			OccurredAt:    clockOut,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.limits.MaxWeeklyHours <= 0 {
+		return nil
+	}
+
+	in := workTime.ClockInTime.UTC()
+	day := time.Date(in.Year(), in.Month(), in.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+
+	shifts, err := s.repo.ListWorkingTimes(ctx, workTime.EmployeeID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return errors.New("failed to load weekly shifts")
+	}
+
+	var weekly float64
+	for _, shift := range shifts {
+		weekly += shift.HoursWorked
+	}
+	if weekly <= s.limits.MaxWeeklyHours {
+		return nil
+	}
+
+	reported, err := s.violations.ListViolations(ctx, workTime.EmployeeID, model.RuleWeeklyCapExceeded, weekStart, clockOut.Add(time.Second))
+	if err != nil {
+		return errors.New("failed to query compliance violations")
+	}
+	if len(reported) > 0 {
+		return nil
+	}
+
+	return s.record(ctx, &model.ComplianceViolation{
+		EmployeeID:    workTime.EmployeeID,
+		WorkingTimeID: workTime.ID,
+		Rule:          model.RuleWeeklyCapExceeded,
+		MeasuredHours: weekly,
+		LimitHours:    s.limits.MaxWeeklyHours,
+		Detail:        fmt.Sprintf("%.2f hours in the week starting %s", weekly, weekStart.Format(time.DateOnly)),
+		OccurredAt:    clockOut,
+	})
+}
+
+// ListViolations returns violations in [from, to); empty filters match everything.
+func (s *ComplianceService) ListViolations(ctx context.Context, employeeID string, rule model.ComplianceRule, from, to time.Time) ([]model.ComplianceViolation, error) {
+	return s.violations.ListViolations(ctx, employeeID, rule, from, to)
+}
+
+// record stores a violation and notifies supervisors. A failed notification is
+// only logged; the violation stays queryable through the API.
+func (s *ComplianceService) record(ctx context.Context, v *model.ComplianceViolation) error {
+	id, err := s.violations.RecordViolation(ctx, v)
+	if err != nil {
+		return errors.New("failed to record compliance violation")
+	}
+	v.ID = id
+
+	event := messaging.ComplianceViolationEvent{
+		ViolationID:   v.ID,
+		EmployeeID:    v.EmployeeID,
+		WorkingTimeID: v.WorkingTimeID,
+		Rule:          string(v.Rule),
+		MeasuredHours: v.MeasuredHours,
+		LimitHours:    v.LimitHours,
+		Detail:        v.Detail,
+		OccurredAt:    v.OccurredAt,
+	}
+	if err := s.producer.Publish(ctx, event); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int64("violation_id", v.ID).Msg("Failed to publish compliance violation")
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// Names of the dead-letter queues, after the queue they belong to.
+const (
+	DeadLetterQueueLabor = "labor"
+	DeadLetterQueueEmail = "email"
+)
+
+var (
+	ErrUnknownDeadLetterQueue = errors.New("unknown dead-letter queue")
+	ErrRedriveSelection       = errors.New("select messages to redrive by ids or set all")
+)
+
+// deadLetterScanLimit is the most dead-lettered messages a listing or redrive looks at.
+const deadLetterScanLimit = 1000
+
+// DeadLetterService lets operators triage the dead-letter queues and send messages back
+// to their queue once the cause of the failure is fixed.
+type DeadLetterService struct {
+	repo   repository.Repository
+	queues map[string]ports.DeadLetterQueue
+	// RedriveRate is how many messages per second a redrive sends back at most.
+	RedriveRate int
+}
+
+// NewDeadLetterService creates the service for the given dead-letter queues, keyed by
+// DeadLetterQueueLabor and DeadLetterQueueEmail.
+func NewDeadLetterService(repo repository.Repository, queues map[string]ports.DeadLetterQueue) *DeadLetterService {
+	return &DeadLetterService{repo: repo, queues: queues, RedriveRate: 10}
+}
+
+// Queues returns the names of the dead-letter queues that can be inspected.
+func (s *DeadLetterService) Queues() []string {
+	names := make([]string, 0, len(s.queues))
+	for name := range s.queues {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// List returns up to limit decoded messages of a dead-letter queue that match filter.
+// truncated reports that the queue holds more messages than a scan looks at, so
+// matching messages beyond the first deadLetterScanLimit are missing.
+func (s *DeadLetterService) List(ctx context.Context, queue string, filter model.DeadLetterFilter, limit int) (messages []model.DeadLetterMessage, truncated bool, err error) {
+	messages, truncated, err = s.matching(ctx, queue, filter)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, truncated, nil
+}
+
+// Redrive sends the messages of a dead-letter queue with the given IDs, or with all
+// set every message, that match filter back to their queue. The retry counters of
+// their records are reset right before each message is sent, so the worker gives it a
+// full retry budget; records of messages that are not moved keep their counters.
+func (s *DeadLetterService) Redrive(ctx context.Context, queue string, ids []string, all bool, filter model.DeadLetterFilter) (*model.RedriveResult, error) {
+	if len(ids) == 0 && !all {
+		return nil, ErrRedriveSelection
+	}
+
+	messages, truncated, err := s.matching(ctx, queue, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.RedriveResult{Queue: queue, Redriven: []string{}, Truncated: truncated}
+	var selected []string
+	workingTimes := make(map[string]int64)
+	for _, msg := range messages {
+		if !all && !slices.Contains(ids, msg.ID) {
+			continue
+		}
+		selected = append(selected, msg.ID)
+		workingTimes[msg.ID] = msg.WorkingTimeID
+	}
+	for _, id := range ids {
+		if !slices.Contains(selected, id) {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	if len(selected) == 0 {
+		return result, nil
+	}
+
+	resetRetries := func(id string) error {
+		if workingTimes[id] == 0 {
+			return nil
+		}
+		parked, err := s.resetRetries(ctx, queue, workingTimes[id])
+		if parked {
+			result.Parked = append(result.Parked, id)
+		}
+		return err
+	}
+	moved, err := s.queues[queue].Redrive(ctx, selected, s.RedriveRate, resetRetries)
+	result.Redriven = append(result.Redriven, moved...)
+	for _, id := range selected {
+		if !slices.Contains(moved, id) && !slices.Contains(result.NotFound, id) {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	log.Ctx(ctx).Info().Str("queue", queue).Int("redriven", len(moved)).Msg("Redrove dead-lettered messages")
+	return result, nil
+}
+
+// matching returns the decoded messages of a dead-letter queue that match filter, and
+// whether the scan stopped at deadLetterScanLimit.
+func (s *DeadLetterService) matching(ctx context.Context, queue string, filter model.DeadLetterFilter) ([]model.DeadLetterMessage, bool, error) {
+	dlq, ok := s.queues[queue]
+	if !ok {
+		return nil, false, ErrUnknownDeadLetterQueue
+	}
+
+	letters, err := dlq.List(ctx, deadLetterScanLimit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := make([]model.DeadLetterMessage, 0, len(letters))
+	for _, letter := range letters {
+		msg := decodeDeadLetter(queue, letter)
+		if matchesDeadLetter(msg, filter) {
+			result = append(result, msg)
+		}
+	}
+	return result, len(letters) >= deadLetterScanLimit, nil
+}
+
+// resetRetries gives the record of a redriven message a fresh retry budget on the
+// queue's channel. parked reports a labor record that is PARKED in the retry schedule
+// instead; it keeps its status and the schedule sends it as well.
+func (s *DeadLetterService) resetRetries(ctx context.Context, queue string, workingTimeID int64) (parked bool, err error) {
+	switch queue {
+	case DeadLetterQueueLabor:
+		reset, err := s.repo.ResetLaborRetries(ctx, workingTimeID)
+		if err != nil || reset {
+			return false, err
+		}
+		record, err := s.repo.GetCheckInOut(ctx, workingTimeID)
+		if err != nil {
+			return false, err
+		}
+		return record.LaborStatus == model.StatusWorkingParked, nil
+	case DeadLetterQueueEmail:
+		_, err = s.repo.ResetEmailRetries(ctx, workingTimeID)
+	}
+	return false, err
+}
+
+// decodeDeadLetter reads the event type, employee and working time of a message. A
+// body that is not JSON is returned as a JSON string.
+func decodeDeadLetter(queue string, letter ports.DeadLetter) model.DeadLetterMessage {
+	msg := model.DeadLetterMessage{
+		ID:           letter.ID,
+		Queue:        queue,
+		Reason:       letter.Reason,
+		ReceiveCount: letter.ReceiveCount,
+		EventType:    letter.Attributes[messaging.EventTypeAttribute],
+	}
+	if !letter.FailedAt.IsZero() {
+		msg.FailedAt = &letter.FailedAt
+	}
+
+	env, err := messaging.Decode(letter.Body)
+	if err != nil {
+		msg.Payload, _ = json.Marshal(string(letter.Body))
+		return msg
+	}
+
+	if env.Type != "" {
+		msg.EventType = env.Type
+	}
+	msg.EmployeeID = messaging.SubjectOf(letter.Body)
+	msg.Payload = env.Data
+
+	var payload struct {
+		WorkingTimeID int64 `json:"workingTimeId"`
+	}
+	_ = json.Unmarshal(env.Data, &payload)
+	msg.WorkingTimeID = payload.WorkingTimeID
+	return msg
+}
+
+func matchesDeadLetter(msg model.DeadLetterMessage, filter model.DeadLetterFilter) bool {
+	if filter.EmployeeID != "" && msg.EmployeeID != filter.EmployeeID {
+		return false
+	}
+	if !filter.From.IsZero() && (msg.FailedAt == nil || msg.FailedAt.Before(filter.From)) {
+		return false
+	}
+	if !filter.To.IsZero() && (msg.FailedAt == nil || !msg.FailedAt.Before(filter.To)) {
+		return false
+	}
+	if filter.Error != "" && !strings.Contains(strings.ToLower(msg.Reason), strings.ToLower(filter.Error)) {
+		return false
+	}
+	return true
+}
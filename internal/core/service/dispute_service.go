@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+var (
+	ErrShiftNotFound          = errors.New("shift not found")
+	ErrDisputeNotFound        = errors.New("dispute not found")
+	ErrDisputeResolved        = errors.New("dispute is already resolved")
+	ErrDisputeCommentRequired = errors.New("comment is required")
+	ErrDisputeNoReviewer      = errors.New("reviewer is required")
+	ErrDisputeInvalidTimes    = errors.New("corrected clock-out must be after clock-in")
+)
+
+// DisputeService lets employees dispute one of their shifts and supervisors
+// resolve the dispute with a correction or a rejection.
+type DisputeService struct {
+	repo     repository.Repository
+	disputes repository.DisputeRepository
+	checkIn  *CheckInService
+}
+
+// NewDisputeService creates the dispute workflow service. Corrections are sent to
+// the labor queue through checkIn, so they follow the same hold rules as check-outs.
+func NewDisputeService(repo repository.Repository, disputes repository.DisputeRepository, checkIn *CheckInService) *DisputeService {
+	return &DisputeService{
+		repo:     repo,
+		disputes: disputes,
+		checkIn:  checkIn,
+	}
+}
+
+// Raise opens a dispute on one of the employee's own shifts. The proposed times are
+// optional and only serve as a suggestion for the supervisor.
+func (s *DisputeService) Raise(ctx context.Context, employeeID string, workingTimeID int64, comment string, proposedClockIn, proposedClockOut *time.Time) (*model.Dispute, error) {
+	if comment == "" {
+		return nil, ErrDisputeCommentRequired
+	}
+
+	wt, err := s.repo.GetCheckInOut(ctx, workingTimeID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && wt.EmployeeID != employeeID) {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load shift")
+	}
+
+	d := &model.Dispute{
+		WorkingTimeID:        workingTimeID,
+		EmployeeID:           employeeID,
+		Comment:              comment,
+		ProposedClockInTime:  proposedClockIn,
+		ProposedClockOutTime: proposedClockOut,
+	}
+	id, err := s.disputes.CreateDispute(ctx, d)
+	if err != nil {
+		return nil, errors.New("failed to create dispute")
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Get returns a single dispute.
+func (s *DisputeService) Get(ctx context.Context, id int64) (*model.Dispute, error) {
+	d, err := s.disputes.GetDispute(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDisputeNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load dispute")
+	}
+	return d, nil
+}
+
+// List returns disputes filtered by employee and status; empty filters match all.
+func (s *DisputeService) List(ctx context.Context, employeeID string, status model.DisputeStatus) ([]model.Dispute, error) {
+	return s.disputes.ListDisputes(ctx, employeeID, status)
+}
+
+// Correct resolves a dispute by overwriting the shift's clock times, together with
+// the times of its job segments. Times that are not given fall back to the
+// employee's proposal and then to the recorded values. The corrected shift is sent
+// to the legacy system again.
+func (s *DisputeService) Correct(ctx context.Context, id int64, reviewer string, clockIn, clockOut *time.Time, comment string) (*model.Dispute, error) {
+	if reviewer == "" {
+		return nil, ErrDisputeNoReviewer
+	}
+
+	d, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != model.StatusDisputeOpen {
+		return nil, ErrDisputeResolved
+	}
+
+	wt, err := s.repo.GetCheckInOut(ctx, d.WorkingTimeID)
+	if err != nil {
+		return nil, errors.New("failed to load shift")
+	}
+
+	if wt.VoidedAt != nil {
+		return nil, ErrShiftVoided
+	}
+
+	newClockIn := firstTime(clockIn, d.ProposedClockInTime, &wt.ClockInTime)
+	newClockOut := firstTime(clockOut, d.ProposedClockOutTime, wt.ClockOutTime)
+	if newClockOut == nil || !newClockOut.After(*newClockIn) {
+		return nil, ErrDisputeInvalidTimes
+	}
+
+	previousIn := wt.ClockInTime
+	var previousOut time.Time
+	if wt.ClockOutTime != nil {
+		previousOut = *wt.ClockOutTime
+	}
+
+	hoursWorked := newClockOut.Sub(*newClockIn).Hours()
+	ok, err := s.disputes.CorrectDispute(ctx, id, wt.ID, *newClockIn, *newClockOut, hoursWorked, reviewer, comment, time.Now().UTC())
+	if err != nil {
+		return nil, errors.New("failed to correct shift")
+	}
+	if !ok {
+		return nil, ErrDisputeResolved
+	}
+
+	wt.ClockInTime = *newClockIn
+	wt.ClockOutTime = newClockOut
+	wt.HoursWorked = hoursWorked
+	wt.Version++
+	if err := s.checkIn.publishCorrection(ctx, wt, previousIn, previousOut, d.ID, reviewer); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Reject resolves a dispute without changing the shift.
+func (s *DisputeService) Reject(ctx context.Context, id int64, reviewer, comment string) (*model.Dispute, error) {
+	if reviewer == "" {
+		return nil, ErrDisputeNoReviewer
+	}
+	if comment == "" {
+		return nil, ErrDisputeCommentRequired
+	}
+
+	ok, err := s.disputes.ResolveDispute(ctx, id, model.StatusDisputeRejected, reviewer, comment, time.Now().UTC())
+	if err != nil {
+		return nil, errors.New("failed to resolve dispute")
+	}
+	if !ok {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrDisputeResolved
+	}
+
+	return s.Get(ctx, id)
+}
+
+// firstTime returns the first non-nil time.
+func firstTime(times ...*time.Time) *time.Time {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LogEmailService is an EmailService that only logs the summary it would send.
+// It is used where no mail provider is available, such as the all-in-one binary.
+type LogEmailService struct{}
+
+func NewLogEmailService() *LogEmailService {
+	return &LogEmailService{}
+}
+
+func (s *LogEmailService) SendCheckOutSummary(ctx context.Context, to string, hours float64) error {
+	log.Ctx(ctx).Info().Str("to", to).Float64("hours", hours).Msg("Check-out summary email (not sent, logging only)")
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrQuarantineNotFound  = errors.New("quarantined message not found")
+	ErrQuarantineResolved  = errors.New("quarantined message was already replayed or discarded")
+	ErrNoReplayDestination = errors.New("messages of this queue cannot be replayed")
+)
+
+// QuarantineService lets operators review the messages workers quarantined as
+// unrecoverable, and replay them to their queue once the cause is fixed or discard them.
+type QuarantineService struct {
+	repo         repository.QuarantineRepository
+	sender       ports.MessageSender
+	destinations map[string]string
+}
+
+// NewQuarantineService creates the quarantine service. Replayed messages are sent
+// through sender to the destination of their queue in destinations; messages of
+// other queues cannot be replayed.
+func NewQuarantineService(repo repository.QuarantineRepository, sender ports.MessageSender, destinations map[string]string) *QuarantineService {
+	return &QuarantineService{repo: repo, sender: sender, destinations: destinations}
+}
+
+// List returns the latest quarantined messages, newest first. Empty queue and status
+// match everything.
+func (s *QuarantineService) List(ctx context.Context, queue string, status model.QuarantineStatus, limit int) ([]model.QuarantinedMessage, error) {
+	return s.repo.ListQuarantined(ctx, queue, status, limit)
+}
+
+// Get returns a quarantined message.
+func (s *QuarantineService) Get(ctx context.Context, id int64) (*model.QuarantinedMessage, error) {
+	m, err := s.repo.GetQuarantined(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuarantineNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load quarantined message")
+	}
+	return m, nil
+}
+
+// Replay sends a quarantined message back to its queue with its original body and
+// attributes, and marks it REPLAYED. If it is rejected again, the worker quarantines
+// it as a new message.
+func (s *QuarantineService) Replay(ctx context.Context, id int64) (*model.QuarantinedMessage, error) {
+	m, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != model.StatusQuarantined {
+		return nil, ErrQuarantineResolved
+	}
+	destination, ok := s.destinations[m.Queue]
+	if !ok || s.sender == nil {
+		return nil, ErrNoReplayDestination
+	}
+
+	// Claiming the message first keeps two operators from replaying it twice.
+	claimed, err := s.repo.SetQuarantineStatus(ctx, id, model.StatusQuarantined, model.StatusQuarantineReplayed)
+	if err != nil {
+		return nil, errors.New("failed to update quarantined message")
+	}
+	if !claimed {
+		return nil, ErrQuarantineResolved
+	}
+
+	opts := []ports.SendOption{
+		ports.WithOrderingKey(m.EmployeeID),
+		ports.WithDeduplicationID(fmt.Sprintf("replay-%d", m.ID)),
+	}
+	for k, v := range m.Attributes {
+		opts = append(opts, ports.WithAttribute(k, v))
+	}
+	if err := s.sender.SendMessage(ctx, destination, []byte(m.Body), opts...); err != nil {
+		if _, rerr := s.repo.SetQuarantineStatus(context.WithoutCancel(ctx), id, model.StatusQuarantineReplayed, model.StatusQuarantined); rerr != nil {
+			log.Ctx(ctx).Error().Err(rerr).Int64("quarantine_id", id).Msg("Failed to return quarantined message to review after a failed replay")
+		}
+		return nil, fmt.Errorf("failed to replay quarantined message: %w", err)
+	}
+
+	log.Ctx(ctx).Info().Int64("quarantine_id", id).Str("queue", m.Queue).Msg("Replayed quarantined message")
+	return s.Get(ctx, id)
+}
+
+// Discard marks a quarantined message as reviewed without replaying it.
+func (s *QuarantineService) Discard(ctx context.Context, id int64) (*model.QuarantinedMessage, error) {
+	ok, err := s.repo.SetQuarantineStatus(ctx, id, model.StatusQuarantined, model.StatusQuarantineDiscarded)
+	if err != nil {
+		return nil, errors.New("failed to update quarantined message")
+	}
+	if !ok {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrQuarantineResolved
+	}
+	return s.Get(ctx, id)
+}
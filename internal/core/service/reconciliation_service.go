@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// reconcileLimit is the most discrepancies of each kind a reconciler run looks at.
+const reconcileLimit = 1000
+
+// ReconciliationService finds working times whose labor or email processing got lost
+// or went wrong. Channels stuck in PENDING past PendingSLA are republished to their
+// own queue once RepublishTo is set, since their message most likely never reached a
+// worker; without it they are only reported. Records with one channel
+// COMPLETED and the other FAILED are only reported; their messages are in the DLQ.
+// Parked labor channels are only reported as well; the retry schedule sends them.
+type ReconciliationService struct {
+	checkIn        *CheckInService
+	reconciliation repository.ReconciliationRepository
+
+	// PendingSLA is how long after check-out a channel may stay PENDING. It must be
+	// longer than a message's retries take; a channel is also republished at most
+	// once per PendingSLA.
+	PendingSLA time.Duration
+
+	// Set by RepublishTo.
+	republisher *ports.Producer
+
+	running sync.Mutex // Held for the duration of a run
+	mu      sync.Mutex // Guards stats
+	stats   model.ReconciliationStats
+}
+
+// NewReconciliationService creates a reconciler that honours checkIn's labor hold.
+func NewReconciliationService(checkIn *CheckInService, reconciliation repository.ReconciliationRepository) *ReconciliationService {
+	return &ReconciliationService{
+		checkIn:        checkIn,
+		reconciliation: reconciliation,
+		PendingSLA:     4 * time.Hour,
+	}
+}
+
+// RepublishTo sends the events of stuck channels through sender, to the labor and
+// email queues only. Republishing through the check-in producer would also deliver
+// the check-out to the events queue and other subscribers a second time.
+func (s *ReconciliationService) RepublishTo(sender ports.MessageSender, labor, email string) {
+	s.republisher = ports.NewProducer(sender, ports.Routes{
+		messaging.TypeCheckedOut:      {labor},
+		messaging.TypeCheckOutSummary: {email},
+	})
+}
+
+// Run reconciles every interval until ctx is canceled.
+func (s *ReconciliationService) Run(ctx context.Context, interval time.Duration) {
+	log.Info().Dur("interval", interval).Dur("pending_sla", s.PendingSLA).Msg("Reconciler started")
+	ctx = log.Logger.WithContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Reconciler stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Msg("Reconciliation failed")
+			}
+		}
+	}
+}
+
+// Reconcile runs the reconciler once and returns its report. Runs never overlap.
+func (s *ReconciliationService) Reconcile(ctx context.Context) (*model.ReconciliationReport, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	found, totals, err := s.reconciliation.FindDiscrepancies(ctx, s.PendingSLA, s.checkIn.timesheets != nil, reconcileLimit)
+	if err != nil {
+		s.record(nil, 0, 0)
+		return nil, errors.New("failed to look up discrepancies")
+	}
+
+	report := &model.ReconciliationReport{
+		RunAt:         time.Now().UTC(),
+		Counts:        make(map[model.DiscrepancyKind]int, len(model.DiscrepancyKinds)),
+		Discrepancies: make([]model.Discrepancy, 0, len(found)),
+	}
+	// Counts are totals; a kind may have more records than the run looks at.
+	for _, kind := range model.DiscrepancyKinds {
+		report.Counts[kind] = totals[kind]
+	}
+
+	var labor, email int64
+	for _, d := range found {
+		republished, held, err := s.republish(ctx, d)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Int64("working_time_id", d.WorkingTimeID).Str("kind", string(d.Kind)).Msg("Failed to republish stuck record")
+		}
+		if held {
+			// Waiting for its timesheet approval is not a discrepancy. The query
+			// leaves these out already; this covers an approval revoked since.
+			report.Counts[d.Kind]--
+			continue
+		}
+
+		d.Republished = republished
+		if republished {
+			report.Republished++
+			if d.Kind == model.DiscrepancyLaborStuck {
+				labor++
+			} else {
+				email++
+			}
+		}
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	s.record(report, labor, email)
+	log.Ctx(ctx).Info().Int("discrepancies", len(report.Discrepancies)).Int("republished", report.Republished).Msg("Reconciliation finished")
+	return report, nil
+}
+
+// Stats returns the reconciler's totals and latest report.
+func (s *ReconciliationService) Stats() model.ReconciliationStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// LastReport returns the report of the latest successful run, or nil before the first.
+func (s *ReconciliationService) LastReport() *model.ReconciliationReport {
+	return s.Stats().Last
+}
+
+// record adds a run to the stats; a nil report is a failed run.
+func (s *ReconciliationService) record(report *model.ReconciliationReport, labor, email int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Runs++
+	if report == nil {
+		s.stats.FailedRuns++
+		return
+	}
+	s.stats.RepublishedLabor += labor
+	s.stats.RepublishedEmail += email
+	s.stats.Last = report
+}
+
+// republish sends the event of a stuck channel again, unless another run did so
+// within the SLA. held reports a labor channel that is held back for its timesheet.
+// When the event cannot be sent the mark is cleared, so the next run tries again.
+func (s *ReconciliationService) republish(ctx context.Context, d model.Discrepancy) (republished, held bool, err error) {
+	if s.republisher == nil {
+		return false, false, nil
+	}
+
+	switch d.Kind {
+	case model.DiscrepancyLaborStuck:
+		wt, err := s.checkIn.repo.GetCheckInOut(ctx, d.WorkingTimeID)
+		if err != nil {
+			return false, false, err
+		}
+		if held, err := s.checkIn.holdsLabor(ctx, wt); err != nil || held {
+			return false, held, err
+		}
+
+		ok, err := s.reconciliation.MarkLaborRepublished(ctx, wt.ID, s.PendingSLA)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		event, err := checkOutEvent(ctx, s.checkIn.repo, wt)
+		if err == nil {
+			err = s.republisher.Publish(ctx, event)
+		}
+		if err != nil {
+			if unmarkErr := s.reconciliation.UnmarkLaborRepublished(ctx, wt.ID, d.LastRepublishedAt); unmarkErr != nil {
+				return false, false, errors.Join(err, unmarkErr)
+			}
+			return false, false, err
+		}
+		return true, false, nil
+
+	case model.DiscrepancyEmailStuck:
+		wt, err := s.checkIn.repo.GetCheckInOut(ctx, d.WorkingTimeID)
+		if err != nil {
+			return false, false, err
+		}
+
+		ok, err := s.reconciliation.MarkEmailRepublished(ctx, wt.ID, s.PendingSLA)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		event := messaging.EmailEvent{
+			WorkingTimeID: wt.ID,
+			EmployeeID:    wt.EmployeeID,
+			HoursWorked:   wt.HoursWorked,
+			OccurredAt:    time.Now(),
+			Version:       wt.Version,
+		}
+		if err := s.republisher.Publish(ctx, event); err != nil {
+			if unmarkErr := s.reconciliation.UnmarkEmailRepublished(ctx, wt.ID, d.LastRepublishedAt); unmarkErr != nil {
+				return false, false, errors.Join(err, unmarkErr)
+			}
+			return false, false, err
+		}
+		return true, false, nil
+
+	default:
+		return false, false, nil
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+)
+
+// fakeReconciliation returns found and totals, and records republish marks.
+type fakeReconciliation struct {
+	repository.ReconciliationRepository
+	found     []model.Discrepancy
+	totals    map[model.DiscrepancyKind]int
+	holdLabor bool
+	marked    []int64
+	unmarked  map[int64]*time.Time
+}
+
+func (r *fakeReconciliation) FindDiscrepancies(ctx context.Context, sla time.Duration, holdLabor bool, limit int) ([]model.Discrepancy, map[model.DiscrepancyKind]int, error) {
+	r.holdLabor = holdLabor
+	return r.found, r.totals, nil
+}
+
+func (r *fakeReconciliation) MarkLaborRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error) {
+	r.marked = append(r.marked, id)
+	return true, nil
+}
+
+func (r *fakeReconciliation) MarkEmailRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error) {
+	r.marked = append(r.marked, id)
+	return true, nil
+}
+
+func (r *fakeReconciliation) UnmarkLaborRepublished(ctx context.Context, id int64, previous *time.Time) error {
+	r.unmarked[id] = previous
+	return nil
+}
+
+func (r *fakeReconciliation) UnmarkEmailRepublished(ctx context.Context, id int64, previous *time.Time) error {
+	r.unmarked[id] = previous
+	return nil
+}
+
+// fakeRecords serves checked-out working times without job segments.
+type fakeRecords struct {
+	repository.Repository
+}
+
+func (r *fakeRecords) GetCheckInOut(ctx context.Context, id int64) (*model.WorkingTime, error) {
+	clockOut := time.Now().Add(-5 * time.Hour)
+	return &model.WorkingTime{ID: id, EmployeeID: "emp-1", ClockOutTime: &clockOut, HoursWorked: 8, Version: 1}, nil
+}
+
+func (r *fakeRecords) ListJobSegments(ctx context.Context, workingTimeID int64) ([]model.JobSegment, error) {
+	return nil, nil
+}
+
+// sentEvent is a message sent through fakeSender.
+type sentEvent struct {
+	destination string
+	eventType   string
+}
+
+// fakeSender records sent messages; with err set every send fails.
+type fakeSender struct {
+	sent []sentEvent
+	err  error
+}
+
+func (s *fakeSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	if s.err != nil {
+		return s.err
+	}
+	o := ports.ApplySendOptions(opts)
+	s.sent = append(s.sent, sentEvent{destination: destination, eventType: o.Attributes[messaging.EventTypeAttribute]})
+	return nil
+}
+
+// newTestReconciler creates a reconciler whose check-in producer sends everywhere,
+// so a republish through it would show up in sender.
+func newTestReconciler(reconciliation *fakeReconciliation, sender *fakeSender) *ReconciliationService {
+	producer := ports.NewProducer(sender, ports.DefaultRoutes(ports.Destinations{
+		Labor: "labor-queue", Email: "email-queue", Events: "events-queue",
+	}))
+	return NewReconciliationService(NewCheckInService(&fakeRecords{}, *producer), reconciliation)
+}
+
+func TestReconcileRepublishesToOwnQueue(t *testing.T) {
+	reconciliation := &fakeReconciliation{
+		found: []model.Discrepancy{
+			{Kind: model.DiscrepancyLaborStuck, WorkingTimeID: 1},
+			{Kind: model.DiscrepancyEmailStuck, WorkingTimeID: 2},
+			{Kind: model.DiscrepancyLaborFailed, WorkingTimeID: 3},
+		},
+		totals: map[model.DiscrepancyKind]int{
+			model.DiscrepancyLaborStuck:  1,
+			model.DiscrepancyEmailStuck:  1,
+			model.DiscrepancyLaborFailed: 1500,
+		},
+		unmarked: map[int64]*time.Time{},
+	}
+	sender := &fakeSender{}
+	s := newTestReconciler(reconciliation, sender)
+	s.RepublishTo(sender, "labor-queue", "email-queue")
+
+	report, err := s.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	want := []sentEvent{
+		{destination: "labor-queue", eventType: messaging.TypeCheckedOut},
+		{destination: "email-queue", eventType: messaging.TypeCheckOutSummary},
+	}
+	if len(sender.sent) != len(want) {
+		t.Fatalf("sent %+v, want %+v", sender.sent, want)
+	}
+	for i := range want {
+		if sender.sent[i] != want[i] {
+			t.Errorf("sent[%d] = %+v, want %+v", i, sender.sent[i], want[i])
+		}
+	}
+	if report.Republished != 2 {
+		t.Errorf("Republished = %d, want 2", report.Republished)
+	}
+	if got := report.Counts[model.DiscrepancyLaborFailed]; got != 1500 {
+		t.Errorf("LABOR_FAILED count = %d, want the total 1500", got)
+	}
+	if got, ok := report.Counts[model.DiscrepancyLaborParked]; !ok || got != 0 {
+		t.Errorf("LABOR_PARKED count = %d (present %v), want 0", got, ok)
+	}
+	if reconciliation.holdLabor {
+		t.Error("FindDiscrepancies() holds labor back without a timesheet hold")
+	}
+}
+
+func TestReconcileClearsMarkWhenPublishFails(t *testing.T) {
+	previous := time.Now().Add(-5 * time.Hour)
+	reconciliation := &fakeReconciliation{
+		found: []model.Discrepancy{
+			{Kind: model.DiscrepancyLaborStuck, WorkingTimeID: 1, LastRepublishedAt: &previous},
+			{Kind: model.DiscrepancyEmailStuck, WorkingTimeID: 2},
+		},
+		unmarked: map[int64]*time.Time{},
+	}
+	sender := &fakeSender{err: errors.New("queue unavailable")}
+	s := newTestReconciler(reconciliation, sender)
+	s.RepublishTo(sender, "labor-queue", "email-queue")
+
+	report, err := s.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if report.Republished != 0 {
+		t.Errorf("Republished = %d, want 0", report.Republished)
+	}
+	if got, ok := reconciliation.unmarked[1]; !ok || got != &previous {
+		t.Errorf("labor mark of record 1 set back to %v (cleared %v), want %v", got, ok, previous)
+	}
+	if got, ok := reconciliation.unmarked[2]; !ok || got != nil {
+		t.Errorf("email mark of record 2 set back to %v (cleared %v), want nil", got, ok)
+	}
+}
+
+func TestReconcileOnlyReportsWithoutRepublisher(t *testing.T) {
+	reconciliation := &fakeReconciliation{
+		found:    []model.Discrepancy{{Kind: model.DiscrepancyLaborStuck, WorkingTimeID: 1}},
+		totals:   map[model.DiscrepancyKind]int{model.DiscrepancyLaborStuck: 1},
+		unmarked: map[int64]*time.Time{},
+	}
+	sender := &fakeSender{}
+	s := newTestReconciler(reconciliation, sender)
+
+	report, err := s.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if len(sender.sent) != 0 || len(reconciliation.marked) != 0 {
+		t.Errorf("sent %+v and marked %v, want nothing", sender.sent, reconciliation.marked)
+	}
+	if len(report.Discrepancies) != 1 || report.Discrepancies[0].Republished {
+		t.Errorf("Discrepancies = %+v, want the stuck record reported only", report.Discrepancies)
+	}
+}
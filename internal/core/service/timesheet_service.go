@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrTimesheetNotFound     = errors.New("timesheet not found")
+	ErrTimesheetTransition   = errors.New("timesheet is not in a state that allows this action")
+	ErrTimesheetOpenShifts   = errors.New("timesheet has shifts that are not checked out yet")
+	ErrTimesheetNoApprover   = errors.New("approver is required")
+	ErrTimesheetNoRejection  = errors.New("rejection reason is required")
+	ErrTimesheetLaborNotHeld = errors.New("labor is not held until timesheet approval")
+)
+
+// TimesheetService runs the pay-period timesheet workflow: employees submit the
+// working times of a period and supervisors approve or reject them.
+type TimesheetService struct {
+	repo       repository.Repository
+	timesheets repository.TimesheetRepository
+	absences   repository.AbsenceRepository
+	producer   ports.Producer
+	calendar   model.PayPeriodCalendar
+	holdLabor  bool
+}
+
+// NewTimesheetService creates the timesheet workflow service. When holdLabor is set,
+// approving a timesheet publishes the check-outs that CheckInService held back.
+func NewTimesheetService(repo repository.Repository, timesheets repository.TimesheetRepository, absences repository.AbsenceRepository, p ports.Producer, calendar model.PayPeriodCalendar, holdLabor bool) *TimesheetService {
+	return &TimesheetService{
+		repo:       repo,
+		timesheets: timesheets,
+		absences:   absences,
+		producer:   p,
+		calendar:   calendar,
+		holdLabor:  holdLabor,
+	}
+}
+
+// OpenTimesheet returns the employee's timesheet for the pay period containing date,
+// opening it if this is the first time it is requested.
+func (s *TimesheetService) OpenTimesheet(ctx context.Context, employeeID string, date time.Time) (*model.Timesheet, error) {
+	start, end := s.calendar.PeriodFor(date)
+
+	ts, err := s.timesheets.GetOrCreateTimesheet(ctx, employeeID, start, end)
+	if err != nil {
+		return nil, errors.New("failed to open timesheet")
+	}
+
+	return ts, s.loadEntries(ctx, ts)
+}
+
+// GetTimesheet returns a timesheet together with the working times it aggregates.
+func (s *TimesheetService) GetTimesheet(ctx context.Context, id int64) (*model.Timesheet, error) {
+	ts, err := s.timesheets.GetTimesheet(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTimesheetNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load timesheet")
+	}
+
+	return ts, s.loadEntries(ctx, ts)
+}
+
+// ListTimesheets returns the timesheets in a given status, e.g. the SUBMITTED
+// ones waiting for a supervisor.
+func (s *TimesheetService) ListTimesheets(ctx context.Context, status model.TimesheetStatus) ([]model.Timesheet, error) {
+	return s.timesheets.ListTimesheets(ctx, status)
+}
+
+// Submit hands a timesheet over to supervisors for review. All shifts of the period
+// must be checked out.
+func (s *TimesheetService) Submit(ctx context.Context, id int64) (*model.Timesheet, error) {
+	ts, err := s.GetTimesheet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range ts.Entries {
+		if e.ClockOutTime == nil {
+			return nil, ErrTimesheetOpenShifts
+		}
+	}
+
+	ok, err := s.timesheets.SubmitTimesheet(ctx, id, ts.TotalHours, time.Now().UTC())
+	if err != nil {
+		return nil, errors.New("failed to submit timesheet")
+	}
+	if !ok {
+		return nil, ErrTimesheetTransition
+	}
+
+	return s.GetTimesheet(ctx, id)
+}
+
+// Approve records the supervisor's approval. If labor publishing is held, the
+// check-outs of the period are published to the labor queue now; the ones that
+// could not be published are listed in UnpublishedLabor and can be retried with
+// PublishLabor.
+func (s *TimesheetService) Approve(ctx context.Context, id int64, approverID string) (*model.Timesheet, error) {
+	if approverID == "" {
+		return nil, ErrTimesheetNoApprover
+	}
+
+	ts, err := s.review(ctx, id, model.StatusTimesheetApproved, approverID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if s.holdLabor {
+		ts.UnpublishedLabor = s.publishHeldLabor(ctx, ts)
+	}
+
+	return ts, nil
+}
+
+// PublishLabor publishes the check-outs of an approved timesheet that are still
+// PENDING, e.g. after publishing them on approval failed. The labor worker skips
+// check-outs that were published before and are completed by now.
+func (s *TimesheetService) PublishLabor(ctx context.Context, id int64) (*model.Timesheet, error) {
+	if !s.holdLabor {
+		return nil, ErrTimesheetLaborNotHeld
+	}
+
+	ts, err := s.GetTimesheet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ts.Status != model.StatusTimesheetApproved {
+		return nil, ErrTimesheetTransition
+	}
+
+	ts.UnpublishedLabor = s.publishHeldLabor(ctx, ts)
+	return ts, nil
+}
+
+// Reject sends a submitted timesheet back to the employee with the supervisor's reason.
+func (s *TimesheetService) Reject(ctx context.Context, id int64, approverID, reason string) (*model.Timesheet, error) {
+	if approverID == "" {
+		return nil, ErrTimesheetNoApprover
+	}
+	if reason == "" {
+		return nil, ErrTimesheetNoRejection
+	}
+
+	return s.review(ctx, id, model.StatusTimesheetRejected, approverID, reason)
+}
+
+func (s *TimesheetService) review(ctx context.Context, id int64, status model.TimesheetStatus, approverID, reason string) (*model.Timesheet, error) {
+	ok, err := s.timesheets.ReviewTimesheet(ctx, id, status, approverID, reason, time.Now().UTC())
+	if err != nil {
+		return nil, errors.New("failed to review timesheet")
+	}
+	if !ok {
+		if _, err := s.timesheets.GetTimesheet(ctx, id); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTimesheetNotFound
+		}
+		return nil, ErrTimesheetTransition
+	}
+
+	return s.GetTimesheet(ctx, id)
+}
+
+// publishHeldLabor publishes the check-outs of an approved timesheet that were not
+// sent to the labor queue yet. It returns the IDs of the working times that could
+// not be published; they are left PENDING.
+func (s *TimesheetService) publishHeldLabor(ctx context.Context, ts *model.Timesheet) []int64 {
+	var failed []int64
+	for i := range ts.Entries {
+		entry := &ts.Entries[i]
+		if entry.ClockOutTime == nil || entry.LaborStatus != model.StatusWorkingPending {
+			continue
+		}
+
+		event, err := checkOutEvent(ctx, s.repo, entry)
+		if err == nil {
+			err = s.producer.Publish(ctx, event)
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Int64("working_time_id", entry.ID).Msg("Failed to publish approved check-out")
+			failed = append(failed, entry.ID)
+		}
+	}
+	return failed
+}
+
+// loadEntries attaches the working times and approved absences of the pay period
+// and totals their hours.
+func (s *TimesheetService) loadEntries(ctx context.Context, ts *model.Timesheet) error {
+	entries, err := s.repo.ListWorkingTimes(ctx, ts.EmployeeID, ts.PeriodStart, ts.PeriodEnd)
+	if err != nil {
+		return errors.New("failed to load timesheet entries")
+	}
+
+	absences, err := s.absences.ListAbsences(ctx, ts.EmployeeID, ts.PeriodStart, ts.PeriodEnd)
+	if err != nil {
+		return errors.New("failed to load timesheet absences")
+	}
+
+	ts.Entries = entries
+	ts.WorkedHours = 0
+	for _, e := range entries {
+		ts.WorkedHours += e.HoursWorked
+	}
+
+	ts.Absences = nil
+	ts.AbsenceHours = 0
+	for _, a := range absences {
+		if a.Status != model.StatusAbsenceApproved {
+			continue
+		}
+		ts.Absences = append(ts.Absences, a)
+		ts.AbsenceHours += a.HoursIn(ts.PeriodStart, ts.PeriodEnd)
+	}
+
+	ts.TotalHours = ts.WorkedHours + ts.AbsenceHours
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/pkg/egress"
+)
+
+var (
+	ErrWebhookNotFound       = errors.New("webhook subscription not found")
+	ErrInvalidWebhookURL     = errors.New("url must be an absolute http or https URL")
+	ErrWebhookURLForbidden   = errors.New("url must not point at an internal address")
+	ErrInvalidWebhookEvents  = errors.New("eventTypes must list one or more shift event types")
+	ErrWebhookSecretTooShort = errors.New("secret must be at least 16 characters")
+)
+
+// WebhookEventTypes are the events a webhook can subscribe to: the shift lifecycle
+// events published to the events queue.
+var WebhookEventTypes = []string{
+	messaging.TypeCheckedIn,
+	messaging.TypeCheckedOut,
+	messaging.TypeShiftCorrected,
+	messaging.TypeShiftVoided,
+}
+
+// minWebhookSecret is the shortest secret accepted for signing deliveries.
+const minWebhookSecret = 16
+
+// WebhookService manages the webhook subscriptions of external systems and their
+// delivery logs. Deliveries themselves are made by the webhook worker.
+type WebhookService struct {
+	webhooks repository.WebhookRepository
+	// AllowPrivateNetworks accepts URLs on loopback and private addresses. Off, a URL
+	// must resolve to public addresses only.
+	AllowPrivateNetworks bool
+}
+
+// NewWebhookService creates the webhook subscription service.
+func NewWebhookService(webhooks repository.WebhookRepository) *WebhookService {
+	return &WebhookService{webhooks: webhooks}
+}
+
+// Subscribe validates and stores a new subscription. The URL's host is resolved, so
+// a subscription cannot make the webhook worker call internal services; the worker
+// checks the address again when it connects.
+func (s *WebhookService) Subscribe(ctx context.Context, sub *model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	if err := egress.CheckURL(ctx, sub.URL, s.AllowPrivateNetworks); err != nil {
+		if errors.Is(err, egress.ErrForbiddenAddress) {
+			return nil, ErrWebhookURLForbidden
+		}
+		return nil, ErrInvalidWebhookURL
+	}
+	if len(sub.EventTypes) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+	for _, t := range sub.EventTypes {
+		if !slices.Contains(WebhookEventTypes, t) {
+			return nil, ErrInvalidWebhookEvents
+		}
+	}
+	if len(sub.Secret) < minWebhookSecret {
+		return nil, ErrWebhookSecretTooShort
+	}
+
+	id, err := s.webhooks.CreateSubscription(ctx, sub)
+	if err != nil {
+		return nil, errors.New("failed to store webhook subscription")
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Get returns a subscription.
+func (s *WebhookService) Get(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	sub, err := s.webhooks.GetSubscription(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, errors.New("failed to load webhook subscription")
+	}
+	return sub, nil
+}
+
+// List returns all subscriptions.
+func (s *WebhookService) List(ctx context.Context) ([]model.WebhookSubscription, error) {
+	return s.webhooks.ListSubscriptions(ctx)
+}
+
+// Unsubscribe deactivates a subscription. Pending deliveries are not sent any more.
+func (s *WebhookService) Unsubscribe(ctx context.Context, id int64) error {
+	ok, err := s.webhooks.DeactivateSubscription(ctx, id)
+	if err != nil {
+		return errors.New("failed to deactivate webhook subscription")
+	}
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Deliveries returns the latest deliveries of a subscription, newest first.
+func (s *WebhookService) Deliveries(ctx context.Context, id int64, status model.WebhookDeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.webhooks.ListDeliveries(ctx, id, status, limit)
+}
@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// DeadLetter is a message in a dead-letter queue.
+type DeadLetter struct {
+	ID         string
+	Body       []byte
+	Attributes map[string]string
+	// Reason and FailedAt are recorded by the worker that dead-lettered the message.
+	// They are empty for messages the broker moved itself, e.g. an SQS redrive policy.
+	Reason       string
+	FailedAt     time.Time
+	ReceiveCount int
+}
+
+// DeadLetterQueue gives operators access to the dead-letter queue of one source queue.
+type DeadLetterQueue interface {
+	// List returns up to max dead-lettered messages; they stay in the queue.
+	List(ctx context.Context, max int) ([]DeadLetter, error)
+	// Redrive sends the dead-lettered messages with the given IDs back to the source
+	// queue, at most perSecond per second, and removes them from the dead-letter
+	// queue. It returns the IDs it moved; IDs it cannot find are skipped. beforeSend,
+	// if set, is called for each message it found right before the message becomes
+	// visible in the source queue; an error stops the redrive.
+	Redrive(ctx context.Context, ids []string, perSecond int, beforeSend func(id string) error) ([]string, error)
+}
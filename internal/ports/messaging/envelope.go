@@ -0,0 +1,165 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents version of the envelope.
+const SpecVersion = "1.0"
+
+// EventSource is the CloudEvents source of every event the service publishes.
+const EventSource = "/checkin-service"
+
+// Event types, in reverse-DNS form as CloudEvents recommends. This is the
+// catalogue of everything the service publishes; see events.go for the payloads.
+const (
+	TypeCheckedIn           = "com.checkin-service.shift.checked-in"
+	TypeCheckedOut          = "com.checkin-service.shift.checked-out"
+	TypeShiftCorrected      = "com.checkin-service.shift.corrected"
+	TypeShiftVoided         = "com.checkin-service.shift.voided"
+	TypeCheckOutSummary     = "com.checkin-service.email.checkout-summary"
+	TypeComplianceViolation = "com.checkin-service.compliance.violation"
+)
+
+// EventTypeAttribute is the message attribute that carries the event type next to
+// the body, for brokers that filter on attributes.
+const EventTypeAttribute = "event_type"
+
+// Event is implemented by every payload published to a queue.
+type Event interface {
+	// EventType is the CloudEvents type, one of the Type constants.
+	EventType() string
+	// SchemaVersion is the version of the payload schema. It is bumped on
+	// changes that old consumers cannot read.
+	SchemaVersion() int
+	// Subject is the employee the event is about; it is also the ordering key.
+	Subject() string
+	// DeduplicationID is stable across re-publishes of the same event and is used
+	// as the CloudEvents id.
+	DeduplicationID() string
+}
+
+// Envelope is a CloudEvents 1.0 event in structured JSON mode.
+type Envelope struct {
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	Data            json.RawMessage `json:"data"`
+}
+
+var (
+	// ErrUnexpectedEventType is returned when an envelope holds a different event type.
+	ErrUnexpectedEventType = errors.New("unexpected event type")
+	// ErrUnsupportedSchema is returned when an envelope's schema is newer than this build understands.
+	ErrUnsupportedSchema = errors.New("unsupported event schema version")
+)
+
+// NewEnvelope wraps event in an envelope created at t.
+func NewEnvelope(event Event, t time.Time) (Envelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		ID:              event.DeduplicationID(),
+		Type:            event.EventType(),
+		Source:          EventSource,
+		SpecVersion:     SpecVersion,
+		DataSchema:      dataSchema(event.EventType(), event.SchemaVersion()),
+		DataContentType: "application/json",
+		Subject:         event.Subject(),
+		Time:            t.UTC(),
+		Data:            data,
+	}, nil
+}
+
+// Encode wraps event in an envelope and marshals it.
+func Encode(event Event) ([]byte, error) {
+	env, err := NewEnvelope(event, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// Decode unmarshals an envelope. A body without a specversion is a bare payload
+// published before the envelope was introduced; it is returned as the Data of an
+// envelope with an empty Type.
+func Decode(body []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return Envelope{}, err
+	}
+	if env.SpecVersion == "" {
+		return Envelope{Data: json.RawMessage(body)}, nil
+	}
+	return env, nil
+}
+
+// SchemaVersion returns the version from the envelope's dataschema, or 1 if it has none.
+func (e Envelope) SchemaVersion() int {
+	i := strings.LastIndex(e.DataSchema, ":v")
+	if i < 0 {
+		return 1
+	}
+	version, err := strconv.Atoi(e.DataSchema[i+2:])
+	if err != nil {
+		return 1
+	}
+	return version
+}
+
+// DecodeEvent decodes a message body into an event of type T. It accepts enveloped
+// events of T's type with a schema version up to T's, and bare legacy payloads.
+func DecodeEvent[T Event](body []byte) (T, Envelope, error) {
+	var event T
+	env, err := Decode(body)
+	if err != nil {
+		return event, env, err
+	}
+
+	if env.Type != "" {
+		if env.Type != event.EventType() {
+			return event, env, fmt.Errorf("%w: got %s, want %s", ErrUnexpectedEventType, env.Type, event.EventType())
+		}
+		if env.SchemaVersion() > event.SchemaVersion() {
+			return event, env, fmt.Errorf("%w: %s", ErrUnsupportedSchema, env.DataSchema)
+		}
+	}
+
+	err = json.Unmarshal(env.Data, &event)
+	return event, env, err
+}
+
+// SubjectOf returns the employee a message body is about, for both enveloped
+// events and bare legacy payloads. It returns "" if the body cannot be read.
+func SubjectOf(body []byte) string {
+	env, err := Decode(body)
+	if err != nil {
+		return ""
+	}
+	if env.Subject != "" {
+		return env.Subject
+	}
+
+	var payload struct {
+		EmployeeID string `json:"employeeId"`
+	}
+	_ = json.Unmarshal(env.Data, &payload)
+	return payload.EmployeeID
+}
+
+func dataSchema(eventType string, version int) string {
+	return fmt.Sprintf("urn:checkin-service:schema:%s:v%d", eventType, version)
+}
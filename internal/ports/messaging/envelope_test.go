@@ -0,0 +1,171 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	clockOut := time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{
+			name:  "checked in",
+			event: CheckedInEvent{WorkingTimeID: 7, EmployeeID: "emp-1", ClockInTime: clockOut.Add(-8 * time.Hour), JobCode: "J1"},
+		},
+		{
+			name: "checked out",
+			event: CheckOutEvent{
+				WorkingTimeID: 7,
+				EmployeeID:    "emp-1",
+				HoursWorked:   8,
+				ClockOutTime:  clockOut,
+				JobLines:      []JobLine{{JobCode: "J1", MinutesWorked: 480}},
+				Version:       2,
+			},
+		},
+		{
+			name:  "voided",
+			event: ShiftVoidedEvent{WorkingTimeID: 7, EmployeeID: "emp-1", VoidedBy: "sup-1", Reason: "duplicate", VoidedAt: clockOut, Version: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Encode(tt.event)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			env, err := Decode(body)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if env.ID != tt.event.DeduplicationID() {
+				t.Errorf("ID = %q, want %q", env.ID, tt.event.DeduplicationID())
+			}
+			if env.Type != tt.event.EventType() {
+				t.Errorf("Type = %q, want %q", env.Type, tt.event.EventType())
+			}
+			if env.Subject != tt.event.Subject() {
+				t.Errorf("Subject = %q, want %q", env.Subject, tt.event.Subject())
+			}
+			if env.SpecVersion != SpecVersion || env.Source != EventSource {
+				t.Errorf("specversion / source = %q / %q, want %q / %q", env.SpecVersion, env.Source, SpecVersion, EventSource)
+			}
+			if got := env.SchemaVersion(); got != tt.event.SchemaVersion() {
+				t.Errorf("SchemaVersion() = %d, want %d", got, tt.event.SchemaVersion())
+			}
+
+			// The payload decodes back into a value of the same type.
+			decoded := reflect.New(reflect.TypeOf(tt.event))
+			if err := json.Unmarshal(env.Data, decoded.Interface()); err != nil {
+				t.Fatalf("unmarshal data: %v", err)
+			}
+			if got := decoded.Elem().Interface(); !reflect.DeepEqual(got, tt.event) {
+				t.Errorf("data = %+v, want %+v", got, tt.event)
+			}
+		})
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	event := CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1}
+	enveloped, err := Encode(event)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	legacy, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal legacy body: %v", err)
+	}
+	newer, err := json.Marshal(Envelope{
+		Type:        TypeCheckedOut,
+		SpecVersion: SpecVersion,
+		DataSchema:  dataSchema(TypeCheckedOut, 2),
+		Data:        json.RawMessage(legacy),
+	})
+	if err != nil {
+		t.Fatalf("marshal newer envelope: %v", err)
+	}
+	voided, err := Encode(ShiftVoidedEvent{WorkingTimeID: 7, EmployeeID: "emp-1"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		wantType string
+		wantErr  error
+	}{
+		{name: "enveloped event", body: enveloped, wantType: TypeCheckedOut},
+		{name: "bare legacy payload", body: legacy, wantType: ""},
+		{name: "newer schema", body: newer, wantType: TypeCheckedOut, wantErr: ErrUnsupportedSchema},
+		{name: "other event type", body: voided, wantType: TypeShiftVoided, wantErr: ErrUnexpectedEventType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, env, err := DecodeEvent[CheckOutEvent](tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if env.Type != tt.wantType {
+				t.Errorf("envelope type = %q, want %q", env.Type, tt.wantType)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, event) {
+				t.Errorf("DecodeEvent() = %+v, want %+v", got, event)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidBody(t *testing.T) {
+	if _, err := Decode([]byte("not json")); err == nil {
+		t.Error("Decode() error = nil for a body that is not JSON")
+	}
+}
+
+func TestSubjectOf(t *testing.T) {
+	enveloped, err := Encode(EmailEvent{EmployeeID: "emp-1"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{name: "enveloped event", body: enveloped, want: "emp-1"},
+		{name: "bare legacy payload", body: []byte(`{"workingTimeId":7,"employeeId":"emp-2"}`), want: "emp-2"},
+		{name: "unreadable body", body: []byte("not json"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SubjectOf(tt.body); got != tt.want {
+				t.Errorf("SubjectOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		dataSchema string
+		want       int
+	}{
+		{dataSchema: dataSchema(TypeCheckedOut, 3), want: 3},
+		{dataSchema: "", want: 1},
+		{dataSchema: "urn:checkin-service:schema:x:vbad", want: 1},
+	}
+	for _, tt := range tests {
+		if got := (Envelope{DataSchema: tt.dataSchema}).SchemaVersion(); got != tt.want {
+			t.Errorf("SchemaVersion() of %q = %d, want %d", tt.dataSchema, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,124 @@
 package messaging
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
-// CheckOutEvent is the JSON payload sent via SQS for checkinout queue
+// CheckedInEvent is published when an employee starts a shift.
+type CheckedInEvent struct {
+	WorkingTimeID int64     `json:"workingTimeId"`
+	EmployeeID    string    `json:"employeeId"`
+	ClockInTime   time.Time `json:"clockInTime"`
+	JobCode       string    `json:"jobCode,omitempty"`
+}
+
+func (e CheckedInEvent) EventType() string  { return TypeCheckedIn }
+func (e CheckedInEvent) SchemaVersion() int { return 1 }
+func (e CheckedInEvent) Subject() string    { return e.EmployeeID }
+
+func (e CheckedInEvent) DeduplicationID() string {
+	return fmt.Sprintf("checkin-%d", e.WorkingTimeID)
+}
+
+// CheckOutEvent is the CheckedOut event, published when a finished shift is
+// released to the labor system. It is wrapped in an Envelope like every event.
 type CheckOutEvent struct {
 	WorkingTimeID int64     `json:"workingTimeId"`
 	EmployeeID    string    `json:"employeeId"`
 	HoursWorked   float64   `json:"hoursWorked"`
 	ClockOutTime  time.Time `json:"clockOutTime"`
+	JobLines      []JobLine `json:"jobLines,omitempty"`
+	// Version is the version of the working time the event was built from.
+	Version int `json:"version"`
+}
+
+func (e CheckOutEvent) EventType() string  { return TypeCheckedOut }
+func (e CheckOutEvent) SchemaVersion() int { return 1 }
+func (e CheckOutEvent) Subject() string    { return e.EmployeeID }
+
+func (e CheckOutEvent) DeduplicationID() string {
+	return fmt.Sprintf("checkout-%d-v%d", e.WorkingTimeID, e.Version)
+}
+
+// ShiftCorrectedEvent is published when a supervisor corrects a shift through a
+// dispute. It carries the corrected shift like a CheckOutEvent, so the labor worker
+// sends it to the legacy system the same way, plus the times it replaces.
+type ShiftCorrectedEvent struct {
+	CheckOutEvent
+	ClockInTime          time.Time `json:"clockInTime"`
+	PreviousClockInTime  time.Time `json:"previousClockInTime"`
+	PreviousClockOutTime time.Time `json:"previousClockOutTime"`
+	DisputeID            int64     `json:"disputeId"`
+	CorrectedBy          string    `json:"correctedBy"`
+}
+
+func (e ShiftCorrectedEvent) EventType() string  { return TypeShiftCorrected }
+func (e ShiftCorrectedEvent) SchemaVersion() int { return 1 }
+
+func (e ShiftCorrectedEvent) DeduplicationID() string {
+	return fmt.Sprintf("correction-%d-v%d", e.WorkingTimeID, e.Version)
+}
+
+// ShiftVoidedEvent is published when a shift is voided and no longer counts.
+type ShiftVoidedEvent struct {
+	WorkingTimeID int64     `json:"workingTimeId"`
+	EmployeeID    string    `json:"employeeId"`
+	VoidedBy      string    `json:"voidedBy"`
+	Reason        string    `json:"reason"`
+	VoidedAt      time.Time `json:"voidedAt"`
+	Version       int       `json:"version"`
 }
 
-// EmailEvent is the JSON payload sent via SQS for email queue
+func (e ShiftVoidedEvent) EventType() string  { return TypeShiftVoided }
+func (e ShiftVoidedEvent) SchemaVersion() int { return 1 }
+func (e ShiftVoidedEvent) Subject() string    { return e.EmployeeID }
+
+func (e ShiftVoidedEvent) DeduplicationID() string {
+	return fmt.Sprintf("void-%d-v%d", e.WorkingTimeID, e.Version)
+}
+
+// JobLine is the labor booked against a single job / work order during a shift.
+// The legacy system records each line as a separate line item.
+type JobLine struct {
+	JobCode       string  `json:"jobCode"`
+	MinutesWorked float64 `json:"minutesWorked"`
+}
+
+// EmailEvent is the JSON payload sent via SQS for email queue, wrapped in an Envelope
 type EmailEvent struct {
 	WorkingTimeID int64     `json:"workingTimeId"`
 	EmployeeID    string    `json:"employeeId"`
 	HoursWorked   float64   `json:"hoursWorked"`
 	OccurredAt    time.Time `json:"occurredAt"`
-}
\ No newline at end of file
+	Version       int       `json:"version"`
+}
+
+func (e EmailEvent) EventType() string  { return TypeCheckOutSummary }
+func (e EmailEvent) SchemaVersion() int { return 1 }
+func (e EmailEvent) Subject() string    { return e.EmployeeID }
+
+func (e EmailEvent) DeduplicationID() string {
+	return fmt.Sprintf("email-%d-v%d", e.WorkingTimeID, e.Version)
+}
+
+// ComplianceViolationEvent is the JSON payload sent via SQS to notify supervisors
+// about a working-time rule violation
+type ComplianceViolationEvent struct {
+	ViolationID   int64     `json:"violationId"`
+	EmployeeID    string    `json:"employeeId"`
+	WorkingTimeID int64     `json:"workingTimeId"`
+	Rule          string    `json:"rule"`
+	MeasuredHours float64   `json:"measuredHours"`
+	LimitHours    float64   `json:"limitHours"`
+	Detail        string    `json:"detail"`
+	OccurredAt    time.Time `json:"occurredAt"`
+}
+
+func (e ComplianceViolationEvent) EventType() string  { return TypeComplianceViolation }
+func (e ComplianceViolationEvent) SchemaVersion() int { return 1 }
+func (e ComplianceViolationEvent) Subject() string    { return e.EmployeeID }
+
+func (e ComplianceViolationEvent) DeduplicationID() string {
+	return fmt.Sprintf("violation-%d", e.ViolationID)
+}
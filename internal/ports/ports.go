@@ -2,21 +2,121 @@ package ports
 
 import (
 	"context"
+	"slices"
+
+	"checkin.service/internal/ports/messaging"
 )
 
 // QeuueProducer defines the output port for publishing domain events.
 type QeuueProducer interface {
-	PublishLabor(ctx context.Context, body interface{}) error
-	PublishEmail(ctx context.Context, body interface{}) error
+	Publish(ctx context.Context, event messaging.Event) error
 }
 
 // MessageSender defines the interface for sending raw messages to a messaging system.
 type MessageSender interface {
-	SendMessage(ctx context.Context, destination string, body []byte) error
+	SendMessage(ctx context.Context, destination string, body []byte, opts ...SendOption) error
+}
+
+// SendOptions are per-message hints for a MessageSender. A sender ignores the
+// hints its broker has no equivalent for.
+type SendOptions struct {
+	// OrderingKey groups messages that must be consumed in order, such as all
+	// events of one employee. Kafka uses it as the record key and SQS FIFO
+	// queues as the message group.
+	OrderingKey string
+	// DeduplicationID identifies a message so that sending it twice delivers it
+	// once, on brokers that deduplicate (SQS FIFO).
+	DeduplicationID string
+	// Attributes are message attributes a broker can route on without reading
+	// the body, such as the event type for SNS subscription filter policies.
+	Attributes map[string]string
+}
+
+type SendOption func(*SendOptions)
+
+// WithOrderingKey sets SendOptions.OrderingKey.
+func WithOrderingKey(key string) SendOption {
+	return func(o *SendOptions) {
+		o.OrderingKey = key
+	}
+}
+
+// WithDeduplicationID sets SendOptions.DeduplicationID.
+func WithDeduplicationID(id string) SendOption {
+	return func(o *SendOptions) {
+		o.DeduplicationID = id
+	}
+}
+
+// WithAttribute adds an entry to SendOptions.Attributes.
+func WithAttribute(key, value string) SendOption {
+	return func(o *SendOptions) {
+		if o.Attributes == nil {
+			o.Attributes = make(map[string]string)
+		}
+		o.Attributes[key] = value
+	}
+}
+
+// ApplySendOptions collects opts into a SendOptions value.
+func ApplySendOptions(opts []SendOption) SendOptions {
+	var o SendOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Routes maps an event type to the destinations it is sent to. Event types
+// without a route are not published.
+type Routes map[string][]string
+
+// Destinations are the queues (or topics) the service publishes to. An empty
+// destination receives nothing, and a destination an event is routed to twice
+// receives it once; setting all of them to one SNS topic publishes every event
+// a single time.
+type Destinations struct {
+	Labor      string
+	Email      string
+	Compliance string
+	// Events is the integration queue that downstream systems consume shift
+	// lifecycle events from.
+	Events string
+}
+
+// DefaultRoutes is the routing table of the service:
+//
+//	CheckedIn           -> events
+//	CheckedOut          -> labor, events
+//	ShiftCorrected      -> labor, events
+//	ShiftVoided         -> events
+//	CheckOutSummary     -> email
+//	ComplianceViolation -> compliance
+func DefaultRoutes(d Destinations) Routes {
+	routes := Routes{}
+	add := func(eventType string, destinations ...string) {
+		for _, destination := range destinations {
+			if destination != "" && !slices.Contains(routes[eventType], destination) {
+				routes[eventType] = append(routes[eventType], destination)
+			}
+		}
+	}
+
+	add(messaging.TypeCheckedIn, d.Events)
+	add(messaging.TypeCheckedOut, d.Labor, d.Events)
+	add(messaging.TypeShiftCorrected, d.Labor, d.Events)
+	add(messaging.TypeShiftVoided, d.Events)
+	add(messaging.TypeCheckOutSummary, d.Email)
+	add(messaging.TypeComplianceViolation, d.Compliance)
+	return routes
 }
 
 type Producer struct {
-	sender        MessageSender
-	laborQueueURL string
-	emailQueueURL string
+	sender MessageSender
+	routes Routes
+	// BestEffort lists destinations whose send failures are logged instead of
+	// failing Publish, such as the events queue: a check-out that reached the labor
+	// queue must not be reported as failed, and retried, because an integration
+	// feed was unavailable.
+	BestEffort []string
 }
@@ -2,48 +2,60 @@ package ports
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
+	"checkin.service/internal/ports/messaging"
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-func NewProducer(sender MessageSender, laborQueueURL, emailQueueURL string) *Producer {
+func NewProducer(sender MessageSender, routes Routes) *Producer {
 	return &Producer{
-		sender:        sender,
-		laborQueueURL: laborQueueURL,
-		emailQueueURL: emailQueueURL,
+		sender: sender,
+		routes: routes,
 	}
 }
 
-func (p *Producer) PublishLabor(ctx context.Context, body interface{}) error {
-	return p.publish(ctx, p.laborQueueURL, body)
-}
-
-func (p *Producer) PublishEmail(ctx context.Context, body interface{}) error {
-	return p.publish(ctx, p.emailQueueURL, body)
+// Publish sends event to every destination routed for its type. It tries all of
+// them and reports the failures together, except those of BestEffort destinations,
+// which are only logged.
+func (p *Producer) Publish(ctx context.Context, event messaging.Event) error {
+	var errs []error
+	for _, destination := range p.routes[event.EventType()] {
+		err := p.publish(ctx, destination, event)
+		if err != nil && slices.Contains(p.BestEffort, destination) {
+			log.Ctx(ctx).Warn().Err(err).Str("destination", destination).Str("event_type", event.EventType()).
+				Msg("Failed to publish to best-effort destination")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (p *Producer) publish(ctx context.Context, destination string, body interface{}) error {
-	b, err := json.Marshal(body)
+func (p *Producer) publish(ctx context.Context, destination string, event messaging.Event) error {
+	b, err := messaging.Encode(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	// Enrich the current span with employee_id if available
-	span := trace.SpanFromContext(ctx)
-	if span.IsRecording() {
-		var payload struct {
-			EmployeeID string `json:"employeeId"`
-		}
-		// Attempt to unmarshal to extract employee_id
-		if err := json.Unmarshal(b, &payload); err == nil && payload.EmployeeID != "" {
-			span.SetAttributes(attribute.String("app.employeeId", payload.EmployeeID))
-		}
-	}
+	// Enrich the current span with employee_id
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("app.employeeId", event.Subject()),
+		attribute.String("messaging.event_type", event.EventType()),
+	)
 
-	if err := p.sender.SendMessage(ctx, destination, b); err != nil {
+	opts := []SendOption{
+		WithOrderingKey(event.Subject()),
+		WithDeduplicationID(event.DeduplicationID()),
+		WithAttribute(messaging.EventTypeAttribute, event.EventType()),
+	}
+	if err := p.sender.SendMessage(ctx, destination, b, opts...); err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	return nil
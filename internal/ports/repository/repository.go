@@ -16,4 +16,88 @@ type Repository interface {
 	FindLastCheckIn(ctx context.Context, employeeID string) (*model.WorkingTime, error)
 	GetStatus(ctx context.Context, id int64) (model.WorkingTimeStatus, error)
 	UpdateEmailStatus(ctx context.Context, id int64, status model.EmailStatus, retryCount int) error
+	StartJobSegment(ctx context.Context, workingTimeID int64, employeeID, jobCode string, start time.Time) (int64, error)
+	CloseJobSegment(ctx context.Context, workingTimeID int64, end time.Time) error
+	ListJobSegments(ctx context.Context, workingTimeID int64) ([]model.JobSegment, error)
+	GetJobLabor(ctx context.Context, employeeID string, from, to time.Time) ([]model.JobLabor, error)
+	ListWorkingTimes(ctx context.Context, employeeID string, from, to time.Time) ([]model.WorkingTime, error)
+	FlagWorkingTime(ctx context.Context, id int64, absenceID int64) error
+	VoidWorkingTime(ctx context.Context, id int64, voidedBy, reason string, voidedAt time.Time) (bool, error)
+	FindLastCheckOut(ctx context.Context, employeeID string, before time.Time) (*model.WorkingTime, error)
+	AcquireLaborLease(ctx context.Context, id int64, version int, owner string, ttl time.Duration) (retryCount int, acquired bool, err error)
+	ReleaseLaborLease(ctx context.Context, id int64, owner string, status model.WorkingTimeStatus, retryCount int) (bool, error)
+	AcquireEmailLease(ctx context.Context, id int64, owner string, ttl time.Duration) (retryCount int, acquired bool, err error)
+	ReleaseEmailLease(ctx context.Context, id int64, owner string, status model.EmailStatus, retryCount int) (bool, error)
+	ResetLaborRetries(ctx context.Context, id int64) (bool, error)
+	ResetEmailRetries(ctx context.Context, id int64) (bool, error)
+}
+
+// TimesheetRepository contract
+type TimesheetRepository interface {
+	GetOrCreateTimesheet(ctx context.Context, employeeID string, periodStart, periodEnd time.Time) (*model.Timesheet, error)
+	FindTimesheet(ctx context.Context, employeeID string, periodStart time.Time) (*model.Timesheet, error)
+	GetTimesheet(ctx context.Context, id int64) (*model.Timesheet, error)
+	ListTimesheets(ctx context.Context, status model.TimesheetStatus) ([]model.Timesheet, error)
+	SubmitTimesheet(ctx context.Context, id int64, totalHours float64, submittedAt time.Time) (bool, error)
+	ReviewTimesheet(ctx context.Context, id int64, status model.TimesheetStatus, reviewer, reason string, reviewedAt time.Time) (bool, error)
+}
+
+// DisputeRepository contract
+type DisputeRepository interface {
+	CreateDispute(ctx context.Context, d *model.Dispute) (int64, error)
+	GetDispute(ctx context.Context, id int64) (*model.Dispute, error)
+	ListDisputes(ctx context.Context, employeeID string, status model.DisputeStatus) ([]model.Dispute, error)
+	ResolveDispute(ctx context.Context, id int64, status model.DisputeStatus, reviewer, comment string, reviewedAt time.Time) (bool, error)
+	CorrectDispute(ctx context.Context, id, workingTimeID int64, clockIn, clockOut time.Time, hoursWorked float64, reviewer, comment string, reviewedAt time.Time) (bool, error)
+}
+
+// AbsenceRepository contract
+type AbsenceRepository interface {
+	UpsertAbsence(ctx context.Context, a *model.Absence) (int64, error)
+	ListAbsences(ctx context.Context, employeeID string, from, to time.Time) ([]model.Absence, error)
+	FindApprovedAbsence(ctx context.Context, employeeID string, at time.Time) (*model.Absence, error)
+}
+
+// ComplianceRepository contract
+type ComplianceRepository interface {
+	RecordViolation(ctx context.Context, v *model.ComplianceViolation) (int64, error)
+	ListViolations(ctx context.Context, employeeID string, rule model.ComplianceRule, from, to time.Time) ([]model.ComplianceViolation, error)
+}
+
+// RetryScheduleRepository contract
+type RetryScheduleRepository interface {
+	Park(ctx context.Context, job *model.ScheduledRetry) error
+	HasParked(ctx context.Context, queue, employeeID string) (bool, error)
+	ClaimDue(ctx context.Context, queue string, employees int, lease time.Duration) ([]model.ScheduledRetry, error)
+	Remove(ctx context.Context, id int64) error
+}
+
+// QuarantineRepository contract
+type QuarantineRepository interface {
+	Quarantine(ctx context.Context, m *model.QuarantinedMessage) (int64, error)
+	GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedMessage, error)
+	ListQuarantined(ctx context.Context, queue string, status model.QuarantineStatus, limit int) ([]model.QuarantinedMessage, error)
+	SetQuarantineStatus(ctx context.Context, id int64, from, to model.QuarantineStatus) (bool, error)
+}
+
+// ReconciliationRepository contract
+type ReconciliationRepository interface {
+	FindDiscrepancies(ctx context.Context, sla time.Duration, holdLabor bool, limit int) ([]model.Discrepancy, map[model.DiscrepancyKind]int, error)
+	MarkLaborRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error)
+	MarkEmailRepublished(ctx context.Context, id int64, sla time.Duration) (bool, error)
+	UnmarkLaborRepublished(ctx context.Context, id int64, previous *time.Time) error
+	UnmarkEmailRepublished(ctx context.Context, id int64, previous *time.Time) error
+}
+
+// WebhookRepository contract
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) (int64, error)
+	GetSubscription(ctx context.Context, id int64) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeactivateSubscription(ctx context.Context, id int64) (bool, error)
+	ListSubscribers(ctx context.Context, eventType string) ([]model.WebhookSubscription, error)
+	EnqueueDelivery(ctx context.Context, subscriptionID int64, eventID, eventType string, payload []byte) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	RecordDeliveryAttempt(ctx context.Context, d *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, status model.WebhookDeliveryStatus, limit int) ([]model.WebhookDelivery, error)
 }
@@ -0,0 +1,49 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a broker-neutral view of a received message.
+type Message struct {
+	ID         string
+	Body       []byte
+	Attributes map[string]string
+	// Handle is the broker's token for settling this delivery (an SQS receipt handle,
+	// for example). Only the MessageSource that produced the message interprets it.
+	Handle string
+	// ReceiveCount is how many times the broker has delivered the message, or 0 if unknown.
+	ReceiveCount int
+	// GroupID is set for messages that must be processed in order with the other
+	// messages of the same group (SQS FIFO message group, Kafka record key).
+	GroupID string
+}
+
+// MessageSource defines the input port a worker consumes messages from.
+type MessageSource interface {
+	// Receive blocks until at least one message is available, ctx is canceled or
+	// the broker's wait time elapses, and returns at most max messages.
+	Receive(ctx context.Context, max int) ([]Message, error)
+	// Ack removes a successfully processed message.
+	Ack(ctx context.Context, msg Message) error
+	// Nack hands a message back so it is redelivered after delay. A zero delay
+	// releases it immediately.
+	Nack(ctx context.Context, msg Message, delay time.Duration) error
+	// Extend keeps a message reserved for another timeout while it is being processed.
+	Extend(ctx context.Context, msg Message, timeout time.Duration) error
+	// DeadLetter moves a message that can never succeed out of the source, recording reason.
+	DeadLetter(ctx context.Context, msg Message, reason string) error
+}
+
+// SystemReporter is implemented by sources that can name their broker, for the
+// messaging.system attribute of a message's trace span.
+type SystemReporter interface {
+	System() string
+}
+
+// DepthReporter is implemented by sources that can tell how many messages are
+// waiting to be received, so a worker can size its concurrency to the backlog.
+type DepthReporter interface {
+	ApproximateDepth(ctx context.Context) (int, error)
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/rs/zerolog/log"
+)
+
+// maxReceive is the most messages requested per receive; SQS accepts no more.
+const maxReceive = 10
+
+// Health is the state of the downstream system a processor depends on.
+type Health int
+
+const (
+	// HealthOK means the downstream system is serving requests.
+	HealthOK Health = iota
+	// HealthDegraded means the downstream system is recovering and only takes a few
+	// trial requests, e.g. a half-open circuit breaker.
+	HealthDegraded
+	// HealthDown means calls would fail right away, e.g. an open circuit breaker.
+	HealthDown
+)
+
+// HealthReporter is implemented by processors that call a downstream system behind a
+// circuit breaker. The worker stops receiving while it is down and runs at minimum
+// concurrency while it is degraded.
+type HealthReporter interface {
+	Health() Health
+}
+
+// pool tracks how many messages are being processed against the current limit, and
+// the processing latency since the limit was last adjusted.
+type pool struct {
+	mu        sync.Mutex
+	limit     int
+	inFlight  int
+	saturated bool
+	latency   time.Duration
+	measured  int
+	// changed is signaled when slots are freed or the limit changes.
+	changed chan struct{}
+}
+
+func newPool(limit int) *pool {
+	return &pool{limit: limit, changed: make(chan struct{}, 1)}
+}
+
+func (p *pool) signal() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// acquire waits until slots are free and reserves up to max of them. It returns 0
+// once ctx is canceled.
+func (p *pool) acquire(ctx context.Context, max int) int {
+	for {
+		p.mu.Lock()
+		free := p.limit - p.inFlight
+		if free > 0 {
+			n := min(free, max)
+			p.inFlight += n
+			p.mu.Unlock()
+			return n
+		}
+		// Waiting for a slot means the limit is holding back work.
+		p.saturated = true
+		p.mu.Unlock()
+
+		select {
+		case <-p.changed:
+		case <-ctx.Done():
+			return 0
+		}
+	}
+}
+
+// release frees n slots.
+func (p *pool) release(n int) {
+	if n == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.inFlight -= n
+	p.mu.Unlock()
+	p.signal()
+}
+
+// observe records how long a message took to process.
+func (p *pool) observe(d time.Duration) {
+	p.mu.Lock()
+	p.latency += d
+	p.measured++
+	p.mu.Unlock()
+}
+
+// poolStats is what the pool saw since the limit was last adjusted.
+type poolStats struct {
+	limit       int
+	saturated   bool
+	meanLatency time.Duration
+}
+
+// stats returns the statistics since the last call and resets them.
+func (p *pool) stats() poolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := poolStats{limit: p.limit, saturated: p.saturated}
+	if p.measured > 0 {
+		s.meanLatency = p.latency / time.Duration(p.measured)
+	}
+	p.saturated = false
+	p.latency = 0
+	p.measured = 0
+	return s
+}
+
+func (p *pool) setLimit(limit int) {
+	p.mu.Lock()
+	p.limit = limit
+	p.mu.Unlock()
+	p.signal()
+}
+
+// health returns the state of the processor's downstream system.
+func (w *Worker) health() Health {
+	if r, ok := w.processor.(HealthReporter); ok {
+		return r.Health()
+	}
+	return HealthOK
+}
+
+// scaleConcurrency adjusts the pool limit every ScaleInterval until ctx is canceled.
+func (w *Worker) scaleConcurrency(ctx context.Context, p *pool) {
+	ticker := time.NewTicker(w.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := p.stats()
+
+			depth := -1
+			if r, ok := w.source.(ports.DepthReporter); ok {
+				d, err := r.ApproximateDepth(ctx)
+				if err != nil {
+					log.Warn().Err(err).Msg("Failed to read queue depth")
+				} else {
+					depth = d
+				}
+			}
+
+			limit := w.nextLimit(stats, depth, w.health())
+			if limit != stats.limit {
+				log.Info().Int("from", stats.limit).Int("to", limit).Int("queue_depth", depth).
+					Dur("mean_latency", stats.meanLatency).Msg("Adjusting worker concurrency")
+				p.setLimit(limit)
+			}
+		}
+	}
+}
+
+// nextLimit decides the concurrency for the next interval. A degraded downstream gets
+// the minimum and a latency above target halves the limit, so a struggling system is
+// relieved quickly. Otherwise a backlog larger than the limit doubles it and an empty
+// queue lowers it by one. depth is -1 if the source cannot report it; whether the
+// worker had to wait for free slots stands in for the backlog then.
+func (w *Worker) nextLimit(stats poolStats, depth int, health Health) int {
+	limit := stats.limit
+	switch {
+	case health != HealthOK:
+		limit = w.MinConcurrency
+	case w.LatencyTarget > 0 && stats.meanLatency > w.LatencyTarget:
+		limit /= 2
+	case depth > limit || (depth < 0 && stats.saturated):
+		limit *= 2
+	case depth == 0 || (depth < 0 && !stats.saturated):
+		limit--
+	}
+	return max(w.MinConcurrency, min(limit, w.MaxConcurrency))
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextLimit(t *testing.T) {
+	w := &Worker{MinConcurrency: 2, MaxConcurrency: 16, LatencyTarget: time.Second}
+
+	tests := []struct {
+		name   string
+		stats  poolStats
+		depth  int
+		health Health
+		want   int
+	}{
+		{name: "backlog doubles", stats: poolStats{limit: 4}, depth: 10, want: 8},
+		{name: "doubling stops at the maximum", stats: poolStats{limit: 12}, depth: 100, want: 16},
+		{name: "backlog within the limit keeps it", stats: poolStats{limit: 4}, depth: 3, want: 4},
+		{name: "empty queue lowers by one", stats: poolStats{limit: 4}, depth: 0, want: 3},
+		{name: "lowering stops at the minimum", stats: poolStats{limit: 2}, depth: 0, want: 2},
+		{name: "slow processing halves", stats: poolStats{limit: 8, meanLatency: 2 * time.Second}, depth: 100, want: 4},
+		{name: "halving stops at the minimum", stats: poolStats{limit: 3, meanLatency: 2 * time.Second}, depth: 100, want: 2},
+		{name: "degraded downstream drops to the minimum", stats: poolStats{limit: 8}, depth: 100, health: HealthDegraded, want: 2},
+		{name: "downstream down drops to the minimum", stats: poolStats{limit: 8}, depth: 100, health: HealthDown, want: 2},
+		{name: "unknown depth and saturated doubles", stats: poolStats{limit: 4, saturated: true}, depth: -1, want: 8},
+		{name: "unknown depth and idle lowers by one", stats: poolStats{limit: 4}, depth: -1, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.nextLimit(tt.stats, tt.depth, tt.health); got != tt.want {
+				t.Errorf("nextLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,126 +2,350 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker/retry"
 	"checkin.service/pkg/logger"
 	"checkin.service/pkg/telemetry"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/rs/zerolog/log"
 )
 
-type SQSClient interface {
-	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
-	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
-	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+// DeadLetterError is returned by a Processor when a message has used up its retry
+// budget. The worker forwards the message to the dead-letter queue with Reason
+// attached, so the queue agrees with the FAILED status in the database.
+type DeadLetterError struct {
+	Reason string
+	Err    error
 }
 
-// Processor is a generic interface for any type that can process a message from SQS.
-// This lets us reuse the main worker logic for different kinds of jobs.
+func (e *DeadLetterError) Error() string {
+	if e.Err == nil {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *DeadLetterError) Unwrap() error {
+	return e.Err
+}
+
+// Retry returns the Process result for an attempt that failed with err, as decided by
+// policy: a retry after the policy's delay, or err alone if it must not be retried.
+func Retry(policy retry.Policy, attempt int, err error) (bool, int32, error) {
+	delay, ok := policy.Next(attempt, err)
+	if !ok {
+		return false, 0, err
+	}
+	return true, RetryDelay(delay), err
+}
+
+// RetryDelay converts a retry delay to the whole seconds a Processor returns, rounding up.
+func RetryDelay(d time.Duration) int32 {
+	return int32((d + time.Second - 1) / time.Second)
+}
+
+// Processor is a generic interface for any type that can process a message from a queue.
+// This lets us reuse the main worker logic for different kinds of jobs. retryDelay is
+// in seconds.
 type Processor interface {
-	Process(ctx context.Context, msg types.Message) (shouldRetry bool, retryDelay int32, err error)
+	Process(ctx context.Context, msg ports.Message) (shouldRetry bool, retryDelay int32, err error)
 }
 
-// Worker is our generic SQS message consumer. It polls a queue and passes
+// Worker is our generic message consumer. It polls a MessageSource and passes
 // messages off to a Processor.
 type Worker struct {
-	client    SQSClient
-	queueURL  string
+	source    ports.MessageSource
 	processor Processor // The logic to process a single message
-	// Concurrency controls how many messages can be processed at the same time.
-	Concurrency int
+	// The number of messages processed at the same time scales between MinConcurrency
+	// and MaxConcurrency every ScaleInterval, following the queue depth, the mean
+	// processing latency against LatencyTarget and the processor's Health.
+	MinConcurrency int
+	MaxConcurrency int
+	LatencyTarget  time.Duration
+	ScaleInterval  time.Duration
+	// PausedPollInterval is how often a worker that stopped receiving because its
+	// downstream is down checks whether it recovered.
+	PausedPollInterval time.Duration
+	// VisibilityTimeout is what the heartbeat extends a message's reservation to
+	// every HeartbeatInterval while its processor is running. A zero value
+	// disables the heartbeat.
+	VisibilityTimeout time.Duration
+	HeartbeatInterval time.Duration
+	// ShutdownTimeout bounds how long Start waits for in-flight messages to finish
+	// once its context is canceled. After that their contexts are canceled too.
+	ShutdownTimeout time.Duration
+	// AbandonTimeout bounds how long Start then waits for processors to return. Those
+	// still running after it are abandoned; their messages are redelivered once their
+	// visibility timeout expires.
+	AbandonTimeout time.Duration
+	// Quarantine, if set, stores messages the processor rejects without a retry and
+	// removes them from the source. Queue names the source in those records, so they
+	// can be replayed to it. Without it such messages stay on the source until its
+	// redrive policy moves them to the dead-letter queue.
+	Quarantine repository.QuarantineRepository
+	Queue      string
 }
 
-// NewWorker creates a new SQS worker, ready to be started.
-func NewWorker(client SQSClient, url string, proc Processor) *Worker {
+// NewWorker creates a new worker for the given source, ready to be started.
+func NewWorker(source ports.MessageSource, proc Processor) *Worker {
 	return &Worker{
-		client:      client,
-		queueURL:    url,
-		processor:   proc,
-		Concurrency: 10, // Default to 10 concurrent processors
+		source:    source,
+		processor: proc,
+
+		MinConcurrency:     1,
+		MaxConcurrency:     10,
+		LatencyTarget:      5 * time.Second,
+		ScaleInterval:      10 * time.Second,
+		PausedPollInterval: time.Second,
+
+		VisibilityTimeout: 30 * time.Second, // The SQS default
+		HeartbeatInterval: 10 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+		AbandonTimeout:    5 * time.Second,
 	}
 }
 
-// Start kicks off the worker's main loop for polling the SQS queue.
-// It will run until the provided context is canceled.
+// Start kicks off the worker's main loop for polling the message source.
+// It runs until the provided context is canceled, then stops polling and waits up
+// to ShutdownTimeout for in-flight messages to finish, plus AbandonTimeout for those
+// whose processors only return once canceled, before returning.
 func (w *Worker) Start(ctx context.Context) {
-	log.Info().Int("concurrency", w.Concurrency).Msg("SQS Worker started. Polling for messages...")
+	log.Info().Int("min_concurrency", w.MinConcurrency).Int("max_concurrency", w.MaxConcurrency).Msg("Worker started. Polling for messages...")
 
-	messagesCh := make(chan types.Message, w.Concurrency)
+	// In-flight messages must not be interrupted by shutdown, so processors run on a
+	// context that is only canceled once the shutdown deadline has passed.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
 
-	// Start a pool of processor goroutines
-	for i := 0; i < w.Concurrency; i++ {
-		go w.processMessages(ctx, messagesCh)
-	}
+	p := newPool(w.MinConcurrency)
+	go w.scaleConcurrency(ctx, p)
 
-	// Start the poller in the main goroutine
-	w.pollMessages(ctx, messagesCh)
-}
+	// Run the poller in the main goroutine until shutdown
+	var wg sync.WaitGroup
+	w.pollMessages(ctx, workCtx, p, &wg)
 
-// pollMessages is the poller loop that fetches messages from SQS and sends them to a channel.
-func (w *Worker) pollMessages(ctx context.Context, messagesCh chan<- types.Message) {
-	defer close(messagesCh) // Close channel to signal processors to stop
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
 
-	for {
+	select {
+	case <-drained:
+		log.Info().Msg("All in-flight messages finished")
+	case <-time.After(w.ShutdownTimeout):
+		log.Warn().Dur("timeout", w.ShutdownTimeout).Msg("Shutdown timeout reached, canceling in-flight messages")
+		cancelWork()
 		select {
-		case <-ctx.Done():
+		case <-drained:
+		case <-time.After(w.AbandonTimeout):
+			log.Error().Dur("timeout", w.AbandonTimeout).Msg("In-flight messages did not stop after being canceled, abandoning them")
+		}
+	}
+}
+
+// pollMessages is the poller loop. It receives only as many messages as the pool has
+// free slots, and none while the processor's downstream is down, so messages are
+// never received just to fail. Messages of the same group are handed to one
+// goroutine together, so they are processed in order.
+func (w *Worker) pollMessages(ctx, workCtx context.Context, p *pool, wg *sync.WaitGroup) {
+	paused := false
+	for {
+		if ctx.Err() != nil {
 			log.Info().Msg("Poller shutting down...")
 			return
-		default:
-			output, err := w.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-				QueueUrl:              &w.queueURL,
-				MaxNumberOfMessages:   int32(w.Concurrency), // Fetch as many messages as we have processors
-				WaitTimeSeconds:       20,
-				MessageAttributeNames: []string{"All"}, // Request attributes to get trace context
-			})
-			if err != nil {
-				log.Error().Err(err).Msg("Error receiving messages")
-				continue
+		}
+
+		if w.health() == HealthDown {
+			if !paused {
+				log.Warn().Msg("Downstream is unavailable, pausing polling")
+				paused = true
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.PausedPollInterval):
 			}
-			log.Info().Int("count", len(output.Messages)).Msg("Received messages")
-			for _, msg := range output.Messages {
-				messagesCh <- msg
+			continue
+		}
+		if paused {
+			log.Info().Msg("Downstream recovered, resuming polling")
+			paused = false
+		}
+
+		slots := p.acquire(ctx, maxReceive)
+		if slots == 0 {
+			continue
+		}
+
+		messages, err := w.source.Receive(ctx, slots)
+		if err != nil {
+			p.release(slots)
+			if ctx.Err() == nil {
+				log.Error().Err(err).Msg("Error receiving messages")
 			}
+			continue
+		}
+		log.Info().Int("count", len(messages)).Msg("Received messages")
+		p.release(slots - len(messages))
+
+		for _, group := range groupMessages(messages) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.processGroup(ctx, workCtx, p, group)
+			}()
 		}
 	}
 }
 
-// processMessages runs in a goroutine, listening for messages on a channel and processing them.
-func (w *Worker) processMessages(ctx context.Context, messagesCh <-chan types.Message) {
-	for msg := range messagesCh {
-		w.handleSingleMessage(ctx, msg)
+// groupMessages splits a batch into messages that can be processed independently. Messages
+// sharing a GroupID stay together in their original order; others form a group of their own.
+func groupMessages(messages []ports.Message) [][]ports.Message {
+	var groups [][]ports.Message
+	index := make(map[string]int)
+	for _, msg := range messages {
+		if msg.GroupID == "" {
+			groups = append(groups, []ports.Message{msg})
+			continue
+		}
+		i, ok := index[msg.GroupID]
+		if !ok {
+			i = len(groups)
+			index[msg.GroupID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], msg)
+	}
+	return groups
+}
+
+// processGroup processes a group of messages in order, each freeing its pool slot when
+// done. When a message is not settled (it will be retried), the rest of its group is
+// released rather than processed, so a later message never overtakes an earlier one.
+// Once ctx is canceled, messages not yet started are released instead of processed.
+func (w *Worker) processGroup(ctx, workCtx context.Context, p *pool, group []ports.Message) {
+	for i, msg := range group {
+		if ctx.Err() != nil {
+			w.releaseMessages(ctx, group[i:])
+			p.release(len(group) - i)
+			return
+		}
+
+		start := time.Now()
+		settled := w.handleSingleMessage(workCtx, msg)
+		p.observe(time.Since(start))
+		p.release(1)
+
+		if !settled {
+			w.releaseMessages(ctx, group[i+1:])
+			p.release(len(group) - i - 1)
+			return
+		}
+	}
+}
+
+// releaseMessages makes received but unprocessed messages visible again immediately, so
+// another worker can pick them up instead of waiting out the visibility timeout.
+// Messages are released one after the other: the Kafka source republishes a released
+// record, so releasing the rest of a group concurrently could reorder it.
+func (w *Worker) releaseMessages(ctx context.Context, messages []ports.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	for _, msg := range messages {
+		if err := w.source.Nack(ctx, msg, 0); err != nil {
+			log.Warn().Err(err).Str("message_id", msg.ID).Msg("Failed to release prefetched message")
+		}
 	}
 }
 
 // handleSingleMessage is where the real work happens for a single message. It calls the
-// processor and then decides whether to delete the message or change its visibility for a retry.
-func (w *Worker) handleSingleMessage(ctx context.Context, msg types.Message) {
-	ctx, span := telemetry.StartSpanFromSQSMessage(ctx, msg)
+// processor and then decides whether to ack the message, nack it for a retry, dead-letter
+// or quarantine it. It reports whether the message was settled, i.e. acked, dead-lettered
+// or quarantined.
+func (w *Worker) handleSingleMessage(ctx context.Context, msg ports.Message) bool {
+	ctx, span := telemetry.StartSpanFromMessage(ctx, w.system(), msg.ID, msg.Attributes, messaging.SubjectOf(msg.Body))
 	defer span.End()
 
 	ctx = logger.EnrichContextWithLogger(ctx)
 
+	stopHeartbeat := w.startHeartbeat(ctx, msg)
 	shouldRetry, retryDelay, err := w.processor.Process(ctx, msg)
+	stopHeartbeat()
 
 	if err != nil && shouldRetry {
 		log.Ctx(ctx).Warn().Err(err).Int32("retry_delay", retryDelay).Msg("Processing failed, will retry")
 
-		_, _ = w.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
-			QueueUrl:          &w.queueURL,
-			ReceiptHandle:     msg.ReceiptHandle,
-			VisibilityTimeout: retryDelay,
-		})
-		return
+		if err := w.source.Nack(ctx, msg, time.Duration(retryDelay)*time.Second); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to hand message back for a retry, it is redelivered after its visibility timeout")
+		}
+		return false
+	}
+
+	var deadLetter *DeadLetterError
+	if errors.As(err, &deadLetter) {
+		if err := w.source.DeadLetter(ctx, msg, deadLetter.Reason); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("reason", deadLetter.Reason).Msg("Retries exhausted but the message could not be dead-lettered, leaving it on the queue")
+			return false
+		}
+		log.Ctx(ctx).Warn().Str("reason", deadLetter.Reason).Msg("Retries exhausted, message moved to dead-letter queue")
+		return true
 	}
 
-	if err == nil {
-		// Only delete on total success
-		w.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
-			QueueUrl:      &w.queueURL,
-			ReceiptHandle: msg.ReceiptHandle,
-		})
-	} else {
+	if err != nil {
 		// An unrecoverable error occurred (e.g., bad message format).
-		log.Ctx(ctx).Error().Err(err).Msg("Unrecoverable error processing message, will not retry")
+		if w.Quarantine == nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Unrecoverable error processing message, will not retry")
+			return false
+		}
+		return w.quarantine(ctx, msg, err)
+	}
+
+	// Only delete on total success
+	if err := w.source.Ack(ctx, msg); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to ack message")
+		return false
+	}
+	return true
+}
+
+// system names the source's broker, if it reports one.
+func (w *Worker) system() string {
+	if r, ok := w.source.(ports.SystemReporter); ok {
+		return r.System()
+	}
+	return ""
+}
+
+// quarantine stores a message the processor rejected and removes it from the source.
+// It reports whether the message was settled.
+func (w *Worker) quarantine(ctx context.Context, msg ports.Message, cause error) bool {
+	id, err := w.Quarantine.Quarantine(ctx, &model.QuarantinedMessage{
+		Queue:        w.Queue,
+		Processor:    fmt.Sprintf("%T", w.processor),
+		MessageID:    msg.ID,
+		EmployeeID:   messaging.SubjectOf(msg.Body),
+		Body:         string(msg.Body),
+		Attributes:   msg.Attributes,
+		Error:        cause.Error(),
+		ReceiveCount: msg.ReceiveCount,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(cause).AnErr("quarantine_error", err).Msg("Unrecoverable error processing message and it could not be quarantined, leaving it on the queue")
+		return false
+	}
+
+	if err := w.source.Ack(ctx, msg); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int64("quarantine_id", id).Msg("Message quarantined but could not be removed from the queue")
+		return false
 	}
+	log.Ctx(ctx).Warn().Err(cause).Int64("quarantine_id", id).Msg("Unrecoverable error processing message, message quarantined")
+	return true
 }
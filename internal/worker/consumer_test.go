@@ -0,0 +1,267 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"checkin.service/internal/ports"
+)
+
+// fakeSource is an in-memory MessageSource that records how each message was settled.
+type fakeSource struct {
+	mu         sync.Mutex
+	pending    []ports.Message
+	acked      []string
+	nacked     map[string]time.Duration
+	nackOrder  []string
+	deadLetter map[string]string
+	ackErr     error
+}
+
+func newFakeSource(messages ...ports.Message) *fakeSource {
+	return &fakeSource{
+		pending:    messages,
+		nacked:     make(map[string]time.Duration),
+		deadLetter: make(map[string]string),
+	}
+}
+
+func (s *fakeSource) Receive(ctx context.Context, max int) ([]ports.Message, error) {
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		n := min(max, len(s.pending))
+		messages := s.pending[:n]
+		s.pending = s.pending[n:]
+		s.mu.Unlock()
+		return messages, nil
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil
+	}
+}
+
+func (s *fakeSource) Ack(ctx context.Context, msg ports.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ackErr != nil {
+		return s.ackErr
+	}
+	s.acked = append(s.acked, msg.ID)
+	return nil
+}
+
+func (s *fakeSource) Nack(ctx context.Context, msg ports.Message, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nacked[msg.ID] = delay
+	s.nackOrder = append(s.nackOrder, msg.ID)
+	return nil
+}
+
+func (s *fakeSource) Extend(ctx context.Context, msg ports.Message, timeout time.Duration) error {
+	return nil
+}
+
+func (s *fakeSource) DeadLetter(ctx context.Context, msg ports.Message, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetter[msg.ID] = reason
+	return nil
+}
+
+// processorFunc adapts a function to the Processor interface.
+type processorFunc func(ctx context.Context, msg ports.Message) (bool, int32, error)
+
+func (f processorFunc) Process(ctx context.Context, msg ports.Message) (bool, int32, error) {
+	return f(ctx, msg)
+}
+
+func TestHandleSingleMessage(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name         string
+		result       func() (bool, int32, error)
+		settled      bool
+		acked        bool
+		nacked       bool
+		nackDelay    time.Duration
+		deadLettered bool
+		reason       string
+	}{
+		{
+			name:    "success is acked",
+			result:  func() (bool, int32, error) { return false, 0, nil },
+			settled: true,
+			acked:   true,
+		},
+		{
+			name:      "retryable error is nacked with the delay",
+			result:    func() (bool, int32, error) { return true, 30, errFailed },
+			nacked:    true,
+			nackDelay: 30 * time.Second,
+		},
+		{
+			name: "exhausted retries are dead-lettered",
+			result: func() (bool, int32, error) {
+				return false, 0, &DeadLetterError{Reason: "gave up", Err: errFailed}
+			},
+			settled:      true,
+			deadLettered: true,
+			reason:       "gave up",
+		},
+		{
+			name:   "unrecoverable error without quarantine stays on the source",
+			result: func() (bool, int32, error) { return false, 0, errFailed },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newFakeSource()
+			w := NewWorker(source, processorFunc(func(ctx context.Context, msg ports.Message) (bool, int32, error) {
+				return tt.result()
+			}))
+
+			settled := w.handleSingleMessage(context.Background(), ports.Message{ID: "m1"})
+			if settled != tt.settled {
+				t.Errorf("settled = %v, want %v", settled, tt.settled)
+			}
+			if acked := len(source.acked) == 1; acked != tt.acked {
+				t.Errorf("acked = %v, want %v", acked, tt.acked)
+			}
+			delay, nacked := source.nacked["m1"]
+			if nacked != tt.nacked || delay != tt.nackDelay {
+				t.Errorf("nacked = %v after %v, want %v after %v", nacked, delay, tt.nacked, tt.nackDelay)
+			}
+			reason, deadLettered := source.deadLetter["m1"]
+			if deadLettered != tt.deadLettered || reason != tt.reason {
+				t.Errorf("dead-lettered = %v with %q, want %v with %q", deadLettered, reason, tt.deadLettered, tt.reason)
+			}
+		})
+	}
+}
+
+func TestHandleSingleMessageAckFailure(t *testing.T) {
+	source := newFakeSource()
+	source.ackErr = errors.New("ack failed")
+	w := NewWorker(source, processorFunc(func(ctx context.Context, msg ports.Message) (bool, int32, error) {
+		return false, 0, nil
+	}))
+
+	if w.handleSingleMessage(context.Background(), ports.Message{ID: "m1"}) {
+		t.Error("settled = true after a failed ack, want false")
+	}
+}
+
+func TestProcessGroupReleasesRestAfterRetry(t *testing.T) {
+	source := newFakeSource()
+	var processed []string
+	w := NewWorker(source, processorFunc(func(ctx context.Context, msg ports.Message) (bool, int32, error) {
+		processed = append(processed, msg.ID)
+		if msg.ID == "m2" {
+			return true, 10, errors.New("failed")
+		}
+		return false, 0, nil
+	}))
+
+	group := []ports.Message{{ID: "m1", GroupID: "g"}, {ID: "m2", GroupID: "g"}, {ID: "m3", GroupID: "g"}, {ID: "m4", GroupID: "g"}}
+	p := newPool(len(group))
+	p.acquire(context.Background(), len(group))
+	w.processGroup(context.Background(), context.Background(), p, group)
+
+	if len(processed) != 2 {
+		t.Fatalf("processed %v, want m1 and m2 only", processed)
+	}
+	if len(source.acked) != 1 || source.acked[0] != "m1" {
+		t.Errorf("acked %v, want [m1]", source.acked)
+	}
+	if delay := source.nacked["m2"]; delay != 10*time.Second {
+		t.Errorf("m2 nacked after %v, want 10s", delay)
+	}
+	for _, id := range []string{"m3", "m4"} {
+		if delay, ok := source.nacked[id]; !ok || delay != 0 {
+			t.Errorf("%s released = %v after %v, want released immediately", id, ok, delay)
+		}
+	}
+	if got := source.nackOrder; len(got) != 3 || got[1] != "m3" || got[2] != "m4" {
+		t.Errorf("nacked in order %v, want [m2 m3 m4]", got)
+	}
+	if p.inFlight != 0 {
+		t.Errorf("%d slots still in use, want 0", p.inFlight)
+	}
+}
+
+func TestStartDrainsAndStops(t *testing.T) {
+	source := newFakeSource(ports.Message{ID: "m1"}, ports.Message{ID: "m2"}, ports.Message{ID: "m3"})
+	done := make(chan string, 3)
+	w := NewWorker(source, processorFunc(func(ctx context.Context, msg ports.Message) (bool, int32, error) {
+		done <- msg.ID
+		return false, 0, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(stopped)
+	}()
+
+	for range 3 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("messages were not processed")
+		}
+	}
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after cancel")
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if len(source.acked) != 3 {
+		t.Errorf("acked %v, want all 3 messages", source.acked)
+	}
+}
+
+func TestStartAbandonsProcessorsIgnoringCancel(t *testing.T) {
+	source := newFakeSource(ports.Message{ID: "m1"})
+	started := make(chan struct{})
+	block := make(chan struct{})
+	defer close(block)
+	w := NewWorker(source, processorFunc(func(ctx context.Context, msg ports.Message) (bool, int32, error) {
+		close(started)
+		<-block
+		return false, 0, nil
+	}))
+	w.ShutdownTimeout = 10 * time.Millisecond
+	w.AbandonTimeout = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(stopped)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start waited for a processor that ignores cancellation")
+	}
+}
@@ -2,21 +2,31 @@ package email
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
+	"time"
 
 	"checkin.service/internal/core/model"
 	core "checkin.service/internal/core/service"
+	"checkin.service/internal/ports"
 	"checkin.service/internal/ports/messaging"
 	"checkin.service/internal/ports/repository"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"checkin.service/internal/worker"
+	"checkin.service/internal/worker/retry"
 	"github.com/rs/zerolog/log"
 )
 
 type EmailProcessor struct {
 	emailService core.EmailService
 	repo         repository.Repository
+	// Retry decides when a failed send is tried again. Its MaxAttempts is how many
+	// sends a record gets before it is marked FAILED.
+	Retry retry.Policy
+	// StoreRetry decides when a message is tried again after the record could not be
+	// read or leased, see labor.LaberProcessor.
+	StoreRetry retry.Policy
+	// LeaseTTL is how long the processing lease on a record lasts, see labor.LaberProcessor.
+	LeaseTTL time.Duration
 }
 
 // NewProcessor sets up a new processor for handling email-related jobs.
@@ -25,22 +35,24 @@ func NewProcessor(emailService core.EmailService, repo repository.Repository) *E
 	return &EmailProcessor{
 		emailService: emailService,
 		repo:         repo,
+		Retry:        retry.NewExponential(20*time.Second, time.Hour, 5),
+		StoreRetry:   retry.NewExponential(5*time.Second, time.Minute, 0),
+		LeaseTTL:     2 * time.Minute,
 	}
 }
 
 // Process is the main entry point for handling a message from the email queue.
 // It tries to send an email and will tell the worker to retry if something goes wrong.
-func (p *EmailProcessor) Process(ctx context.Context, msg types.Message) (bool, int32, error) {
-	var event messaging.EmailEvent
-	if err := json.Unmarshal([]byte(*msg.Body), &event); err != nil {
+func (p *EmailProcessor) Process(ctx context.Context, msg ports.Message) (bool, int32, error) {
+	event, _, err := messaging.DecodeEvent[messaging.EmailEvent](msg.Body)
+	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("Failed to unmarshal email event")
 		return false, 0, err // Do not retry on malformed message
 	}
 
 	record, err := p.repo.GetCheckInOut(ctx, event.WorkingTimeID)
 	if err != nil {
-		// If we can't get the record, retry after a short delay.
-		return true, 10, fmt.Errorf("failed to get record from db for email processing: %w", err)
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to get record from db for email processing: %w", err))
 	}
 
 	if record.EmailStatus == model.StatusEmailCompleted {
@@ -48,25 +60,66 @@ func (p *EmailProcessor) Process(ctx context.Context, msg types.Message) (bool,
 		return false, 0, nil
 	}
 
+	if record.VoidedAt != nil {
+		log.Ctx(ctx).Info().Int64("working_time_id", event.WorkingTimeID).Msg("Shift was voided. Skipping email.")
+		return false, 0, nil
+	}
+
+	if record.EmailStatus == model.StatusEmailFailed {
+		// A previous attempt gave up but could not move the message to the DLQ.
+		return false, 0, &worker.DeadLetterError{Reason: "email record is already FAILED"}
+	}
+
+	lease := worker.NewLeaseToken()
+	retryCount, acquired, err := p.repo.AcquireEmailLease(ctx, event.WorkingTimeID, lease, p.LeaseTTL)
+	if err != nil {
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to acquire processing lease: %w", err))
+	}
+	if !acquired {
+		// Another worker is sending this email right now; look again once its lease ran out.
+		return true, int32(p.LeaseTTL.Seconds()), errors.New("record is leased by another worker")
+	}
+	// The previous lease holder may have counted another attempt since the record was read.
+	record.EmailRetryCount = retryCount
+
 	err = p.emailService.SendCheckOutSummary(ctx, event.EmployeeID+"@factory.com", event.HoursWorked)
 	if err != nil {
 		newCount := record.EmailRetryCount + 1
-		p.repo.UpdateEmailStatus(ctx, event.WorkingTimeID, model.StatusEmailPending, newCount)
+		delay, ok := p.Retry.Next(newCount, err)
+		if !ok {
+			if relErr := p.release(ctx, lease, event.WorkingTimeID, model.StatusEmailFailed, newCount); relErr != nil {
+				// Dead-lettered on a later delivery, once the record is FAILED.
+				return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to mark record FAILED: %w", relErr))
+			}
+			reason := fmt.Sprintf("email send failed after %d attempts", newCount)
+			if retry.IsPermanent(err) {
+				reason = "email was rejected"
+			}
+			return false, 0, &worker.DeadLetterError{Reason: reason, Err: err}
+		}
+		if relErr := p.release(ctx, lease, event.WorkingTimeID, model.StatusEmailPending, newCount); relErr != nil {
+			return true, worker.RetryDelay(delay), errors.Join(err, fmt.Errorf("failed to release record: %w", relErr))
+		}
 
-		delay := calculateBackoff(newCount)
-		return true, delay, err
+		return true, worker.RetryDelay(delay), err
 	}
 
-	err = p.repo.UpdateEmailStatus(ctx, event.WorkingTimeID, model.StatusEmailCompleted, 0)
-	return false, 0, err
+	if err := p.release(ctx, lease, event.WorkingTimeID, model.StatusEmailCompleted, 0); err != nil {
+		// The email was sent. A retry sends it again once the lease ran out, which beats
+		// quarantining the message and leaving the record PROCESSING.
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to mark record COMPLETED: %w", err))
+	}
+	return false, 0, nil
 }
 
-// calculateBackoff determines how long to wait before retrying a failed job.
-// It increases the delay exponentially with each retry to avoid overwhelming a struggling service.
-func calculateBackoff(retryCount int) int32 {
-	backoff := int32(math.Pow(2, float64(retryCount)) * 10)
-	if backoff > 3600 { // Cap at 1 hour
-		return 3600
+// release records the outcome of an attempt and gives up the processing lease.
+func (p *EmailProcessor) release(ctx context.Context, lease string, id int64, status model.EmailStatus, retryCount int) error {
+	released, err := p.repo.ReleaseEmailLease(ctx, id, lease, status, retryCount)
+	if err != nil {
+		return err
+	}
+	if !released {
+		log.Ctx(ctx).Warn().Int64("working_time_id", id).Str("status", string(status)).Msg("Processing lease was lost before the status could be set")
 	}
-	return backoff
+	return nil
 }
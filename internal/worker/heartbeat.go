@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"checkin.service/internal/ports"
+	"github.com/rs/zerolog/log"
+)
+
+// startHeartbeat keeps a message reserved while its processor is running by
+// extending its visibility timeout every HeartbeatInterval. The returned function
+// stops the heartbeat and waits for it to finish, so no extension can race with
+// the delete or retry that follows. The heartbeat also stops when ctx is canceled.
+func (w *Worker) startHeartbeat(ctx context.Context, msg ports.Message) (stop func()) {
+	if w.HeartbeatInterval <= 0 || w.VisibilityTimeout <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := w.source.Extend(ctx, msg, w.VisibilityTimeout)
+				if err != nil && ctx.Err() == nil {
+					log.Ctx(ctx).Warn().Err(err).Msg("Failed to extend message visibility")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// InstanceID returns an identifier that is unique to this worker process
+// (hostname, PID and a random suffix).
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		suffix := make([]byte, 4)
+		rand.Read(suffix)
+		instanceID = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+	})
+	return instanceID
+}
+
+// NewLeaseToken returns a token that identifies one processing lease. It starts with
+// InstanceID, so a lease owner in the database shows which process holds it, but it
+// differs between the goroutines of a process and between acquisitions.
+func NewLeaseToken() string {
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	return InstanceID() + "-" + hex.EncodeToString(suffix)
+}
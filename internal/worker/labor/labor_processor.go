@@ -2,27 +2,51 @@ package labor
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"math"
 	"time"
 
 	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
 	"checkin.service/internal/ports/messaging"
 	"checkin.service/internal/ports/repository"
-	legacyAPI "checkin.service/internal/worker/legacyapi"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"checkin.service/internal/worker"
+	legacyAPI "checkin.service/internal/worker/legacyAPI"
+	"checkin.service/internal/worker/retry"
+	"checkin.service/internal/worker/schedule"
 	"github.com/sony/gobreaker"
 )
 
+// ScheduleQueue is the labor queue's name in the retry schedule.
+const ScheduleQueue = "labor"
+
+// breakerTimeout is how long the legacy API breaker stays open. A message that finds
+// it open is tried again after that long.
+const breakerTimeout = 30 * time.Second
+
 // LaberProcessor handles jobs from the labor queue, which involves calling a legacy API.
 // It uses a circuit breaker to avoid hammering the legacy system if it's having issues.
 type LaberProcessor struct {
 	Repo      repository.Repository
 	legacyapi legacyAPI.LegacyAPIClient
 	cb        *gobreaker.CircuitBreaker
+	// Retry decides when a failed legacy API call is tried again. Its MaxAttempts is
+	// how many calls a record gets before it is marked FAILED.
+	Retry retry.Policy
+	// StoreRetry decides when a message is tried again after the record could not be
+	// read or leased. It counts the message's deliveries, not legacy API calls.
+	StoreRetry retry.Policy
+	// Schedule, if set, is where records whose retries ran out are parked until the
+	// legacy system is back, instead of being marked FAILED. While an employee has a
+	// parked record their later records are parked behind it, to keep their order.
+	Schedule repository.RetryScheduleRepository
+	// ParkRetry decides when a parked record is sent again. Its MaxAttempts is how
+	// many times a record is parked before it is marked FAILED.
+	ParkRetry retry.Policy
+	// LeaseTTL is how long the processing lease on a record lasts. It must be longer
+	// than a legacy API call; an expired lease can be reclaimed by another worker.
+	LeaseTTL time.Duration
 }
 
 // NewProcessor creates a new processor for the labor queue. It sets up a
@@ -32,7 +56,7 @@ func NewProcessor(r repository.Repository, legacyapi legacyAPI.LegacyAPIClient)
 		Name:        "Legacy-API",
 		MaxRequests: 5,
 		Interval:    60 * time.Second,
-		Timeout:     30 * time.Second,
+		Timeout:     breakerTimeout,
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			// Trip if failure rate is bigger then 50% after at least 10 requests
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
@@ -41,17 +65,34 @@ func NewProcessor(r repository.Repository, legacyapi legacyAPI.LegacyAPIClient)
 	}
 
 	return &LaberProcessor{
-		Repo:      r,
-		legacyapi: legacyapi,
-		cb:        gobreaker.NewCircuitBreaker(settings),
+		Repo:       r,
+		legacyapi:  legacyapi,
+		cb:         gobreaker.NewCircuitBreaker(settings),
+		Retry:      retry.NewExponential(20*time.Second, time.Hour, 5),
+		StoreRetry: retry.NewExponential(5*time.Second, time.Minute, 0),
+		ParkRetry:  retry.NewExponential(15*time.Minute, 6*time.Hour, 30),
+		LeaseTTL:   2 * time.Minute,
+	}
+}
+
+// Health reports the state of the legacy API breaker, so the worker stops receiving
+// labor messages while the breaker is open.
+func (p *LaberProcessor) Health() worker.Health {
+	switch p.cb.State() {
+	case gobreaker.StateOpen:
+		return worker.HealthDown
+	case gobreaker.StateHalfOpen:
+		return worker.HealthDegraded
+	default:
+		return worker.HealthOK
 	}
 }
 
 // Process is the core logic for handling a message from the labor queue.
-// It calls the legacy API through a circuit breaker and handles retries with exponential backoff.
-func (p *LaberProcessor) Process(ctx context.Context, msg types.Message) (bool, int32, error) {
-	var event messaging.CheckOutEvent
-	if err := json.Unmarshal([]byte(*msg.Body), &event); err != nil {
+// It calls the legacy API through a circuit breaker and retries failed calls as Retry decides.
+func (p *LaberProcessor) Process(ctx context.Context, msg ports.Message) (bool, int32, error) {
+	event, err := decodeLaborEvent(msg.Body)
+	if err != nil {
 		log.Printf("Failed to unmarshal labor event: %v", err)
 		return false, 0, err // Do not retry on malformed message
 	}
@@ -60,38 +101,155 @@ func (p *LaberProcessor) Process(ctx context.Context, msg types.Message) (bool,
 
 	record, err := p.Repo.GetCheckInOut(ctx, event.WorkingTimeID)
 	if err != nil {
-		return true, 10, fmt.Errorf("failed to get record from db: %w", err)
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to get record from db: %w", err))
+	}
+
+	if event.Version < record.Version {
+		// The shift was corrected since; the event of the new version sends it.
+		log.Printf("Skipping version %d of working time %d, which is at version %d", event.Version, record.ID, record.Version)
+		return false, 0, nil
+	}
+
+	if record.LaborStatus == model.StatusWorkingCompleted && event.Version == record.Version {
+		return false, 0, nil
 	}
 
-	if record.LaborStatus == model.StatusWorkingCompleted {
+	if record.VoidedAt != nil {
+		log.Printf("Skipping voided shift %d", record.ID)
 		return false, 0, nil
 	}
 
+	if record.LaborStatus == model.StatusWorkingFailed {
+		// A previous attempt gave up but could not move the message to the DLQ.
+		return false, 0, &worker.DeadLetterError{Reason: "labor record is already FAILED"}
+	}
+
+	lease := worker.NewLeaseToken()
+	// The lease is only taken at the event's version, so a correction made since the
+	// record was read is not marked COMPLETED with the old times.
+	retryCount, acquired, err := p.Repo.AcquireLaborLease(ctx, event.WorkingTimeID, event.Version, lease, p.LeaseTTL)
+	if err != nil {
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to acquire processing lease: %w", err))
+	}
+	if !acquired {
+		// Another worker is processing this record right now, or it was corrected;
+		// look again once the lease ran out.
+		return true, int32(p.LeaseTTL.Seconds()), errors.New("record is leased by another worker or was corrected")
+	}
+	// The previous lease holder may have counted another attempt since the record was read.
+	record.LaborRetryCount = retryCount
+
+	if p.Schedule != nil {
+		parked, err := p.Schedule.HasParked(ctx, ScheduleQueue, record.EmployeeID)
+		if err != nil {
+			err = errors.Join(fmt.Errorf("failed to look up parked records: %w", err),
+				p.release(ctx, lease, event.WorkingTimeID, model.StatusWorkingPending, record.LaborRetryCount))
+			return worker.Retry(p.StoreRetry, msg.ReceiveCount, err)
+		}
+		if parked {
+			// Sending it now would overtake the employee's parked records.
+			return p.park(ctx, lease, msg, record, schedule.Attempts(msg), 0, errors.New("parked behind earlier records of the employee"))
+		}
+	}
+
 	_, err = p.cb.Execute(func() (interface{}, error) {
 		return nil, p.legacyapi.RecordCheckOut(ctx, event)
 	})
 
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		// The legacy API was not called, so this does not count as an attempt.
+		if relErr := p.release(ctx, lease, event.WorkingTimeID, model.StatusWorkingPending, record.LaborRetryCount); relErr != nil {
+			return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to release record: %w", relErr))
+		}
+		return true, worker.RetryDelay(breakerTimeout), err
+	}
+
 	if err != nil {
-		if errors.Is(err, gobreaker.ErrOpenState) {
-			fmt.Println("Circuit Breaker is OPEN; skipping Legacy API call")
+		newCount := record.LaborRetryCount + 1
+		delay, ok := p.Retry.Next(newCount, err)
+		if !ok && p.Schedule != nil && !retry.IsPermanent(err) {
+			attempts := schedule.Attempts(msg) + 1
+			if parkDelay, park := p.ParkRetry.Next(attempts, err); park {
+				return p.park(ctx, lease, msg, record, attempts, parkDelay, err)
+			}
+		}
+		if !ok {
+			if relErr := p.release(ctx, lease, event.WorkingTimeID, model.StatusWorkingFailed, newCount); relErr != nil {
+				// Dead-lettered on a later delivery, once the record is FAILED.
+				return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to mark record FAILED: %w", relErr))
+			}
+			reason := fmt.Sprintf("legacy API call failed after %d attempts", newCount)
+			if retry.IsPermanent(err) {
+				reason = "legacy API rejected the check-out"
+			}
+			return false, 0, &worker.DeadLetterError{Reason: reason, Err: err}
+		}
+		if relErr := p.release(ctx, lease, event.WorkingTimeID, model.StatusWorkingPending, newCount); relErr != nil {
+			return true, worker.RetryDelay(delay), errors.Join(err, fmt.Errorf("failed to release record: %w", relErr))
 		}
-		newCount := record.RetryCount + 1
-		p.Repo.UpdateLaborStatus(ctx, event.WorkingTimeID, model.StatusWorkingPending, newCount)
 
-		delay := calculateBackoff(newCount)
-		return true, delay, err
+		return true, worker.RetryDelay(delay), err
 	}
 
-	err = p.Repo.UpdateLaborStatus(ctx, event.WorkingTimeID, model.StatusWorkingCompleted, 0)
-	return false, 0, err
+	if err := p.release(ctx, lease, event.WorkingTimeID, model.StatusWorkingCompleted, 0); err != nil {
+		// The legacy system already has the check-out. A retry sends it again once the
+		// lease ran out, which beats quarantining the message and leaving the record
+		// PROCESSING.
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, fmt.Errorf("failed to mark record COMPLETED: %w", err))
+	}
+	return false, 0, nil
 }
 
-// calculateBackoff determines how long to wait before retrying a failed job.
-// It increases the delay exponentially with each retry.
-func calculateBackoff(retryCount int) int32 {
-	backoff := int32(math.Pow(2, float64(retryCount)) * 10)
-	if backoff > 3600 {
-		return 3600 // max at 1 hour
+// park puts the message in the retry schedule to be sent again after delay, and
+// leaves the record PARKED with a fresh retry budget.
+func (p *LaberProcessor) park(ctx context.Context, lease string, msg ports.Message, record *model.WorkingTime, attempts int, delay time.Duration, cause error) (bool, int32, error) {
+	err := p.Schedule.Park(ctx, &model.ScheduledRetry{
+		Queue:         ScheduleQueue,
+		WorkingTimeID: record.ID,
+		EmployeeID:    record.EmployeeID,
+		Body:          msg.Body,
+		Attributes:    msg.Attributes,
+		Attempts:      attempts,
+		NextAttemptAt: time.Now().Add(delay),
+		LastError:     cause.Error(),
+	})
+	if err != nil {
+		err = errors.Join(fmt.Errorf("failed to park record: %w", err),
+			p.release(ctx, lease, record.ID, model.StatusWorkingPending, record.LaborRetryCount))
+		return worker.Retry(p.StoreRetry, msg.ReceiveCount, err)
 	}
-	return backoff
+
+	log.Printf("Parked working time %d in the retry schedule for %s (attempt %d): %v", record.ID, delay.Round(time.Second), attempts, cause)
+	return false, 0, p.release(ctx, lease, record.ID, model.StatusWorkingParked, 0)
+}
+
+// release records the outcome of an attempt and gives up the processing lease.
+// Losing the lease means it expired or the record was corrected meanwhile; the
+// outcome is then left to whoever holds the record now.
+func (p *LaberProcessor) release(ctx context.Context, lease string, id int64, status model.WorkingTimeStatus, retryCount int) error {
+	released, err := p.Repo.ReleaseLaborLease(ctx, id, lease, status, retryCount)
+	if err != nil {
+		return err
+	}
+	if !released {
+		log.Printf("Processing lease on working time %d was lost before it could be set to %s", id, status)
+	}
+	return nil
+}
+
+// decodeLaborEvent reads the shift to send to the legacy system from a CheckedOut
+// or ShiftCorrected event. A correction is sent like a check-out with its new times.
+func decodeLaborEvent(body []byte) (messaging.CheckOutEvent, error) {
+	env, err := messaging.Decode(body)
+	if err != nil {
+		return messaging.CheckOutEvent{}, err
+	}
+
+	if env.Type == messaging.TypeShiftCorrected {
+		event, _, err := messaging.DecodeEvent[messaging.ShiftCorrectedEvent](body)
+		return event.CheckOutEvent, err
+	}
+
+	event, _, err := messaging.DecodeEvent[messaging.CheckOutEvent](body)
+	return event, err
 }
@@ -0,0 +1,334 @@
+package labor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker"
+	"checkin.service/internal/worker/retry"
+	"checkin.service/internal/worker/schedule"
+)
+
+// fakeRepo serves one record and records how its lease was released. The lease is
+// only acquired at the record's version.
+type fakeRepo struct {
+	repository.Repository
+	record     model.WorkingTime
+	released   []model.WorkingTimeStatus
+	retries    []int
+	releaseErr error
+}
+
+func (r *fakeRepo) GetCheckInOut(ctx context.Context, id int64) (*model.WorkingTime, error) {
+	record := r.record
+	return &record, nil
+}
+
+func (r *fakeRepo) AcquireLaborLease(ctx context.Context, id int64, version int, owner string, ttl time.Duration) (int, bool, error) {
+	return r.record.LaborRetryCount, version == r.record.Version, nil
+}
+
+func (r *fakeRepo) ReleaseLaborLease(ctx context.Context, id int64, owner string, status model.WorkingTimeStatus, retryCount int) (bool, error) {
+	r.released = append(r.released, status)
+	r.retries = append(r.retries, retryCount)
+	if r.releaseErr != nil {
+		return false, r.releaseErr
+	}
+	r.record.LaborStatus = status
+	return true, nil
+}
+
+// fakeSchedule records parked jobs.
+type fakeSchedule struct {
+	repository.RetryScheduleRepository
+	hasParked bool
+	parkErr   error
+	parked    []model.ScheduledRetry
+}
+
+func (s *fakeSchedule) HasParked(ctx context.Context, queue, employeeID string) (bool, error) {
+	return s.hasParked, nil
+}
+
+func (s *fakeSchedule) Park(ctx context.Context, job *model.ScheduledRetry) error {
+	if s.parkErr != nil {
+		return s.parkErr
+	}
+	s.parked = append(s.parked, *job)
+	return nil
+}
+
+// fakeLegacy records the check-outs it is sent and fails every call with err.
+type fakeLegacy struct {
+	err   error
+	calls int
+	sent  []messaging.CheckOutEvent
+}
+
+func (l *fakeLegacy) RecordCheckOut(ctx context.Context, event messaging.CheckOutEvent) error {
+	l.calls++
+	l.sent = append(l.sent, event)
+	return l.err
+}
+
+func TestProcessParksExhaustedRecords(t *testing.T) {
+	errUnavailable := errors.New("legacy API unavailable")
+	body, err := messaging.Encode(messaging.CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		// retryCount is the record's labor retry count before this attempt.
+		retryCount     int
+		parkAttempts   int // schedule_attempts of the message
+		hasParked      bool
+		legacyErr      error
+		parkErr        error
+		wantCalls      int
+		wantRetry      bool
+		wantDeadLetter bool
+		wantReleased   model.WorkingTimeStatus
+		wantParked     bool
+		wantAttempts   int
+		wantNextAfter  time.Duration
+	}{
+		{
+			name:         "retries left",
+			legacyErr:    errUnavailable,
+			wantCalls:    1,
+			wantRetry:    true,
+			wantReleased: model.StatusWorkingPending,
+		},
+		{
+			name:          "retries used up parks the record",
+			retryCount:    1,
+			parkAttempts:  1,
+			legacyErr:     errUnavailable,
+			wantCalls:     1,
+			wantReleased:  model.StatusWorkingParked,
+			wantParked:    true,
+			wantAttempts:  2,
+			wantNextAfter: time.Hour,
+		},
+		{
+			name:           "parks used up marks the record FAILED",
+			retryCount:     1,
+			parkAttempts:   3,
+			legacyErr:      errUnavailable,
+			wantCalls:      1,
+			wantDeadLetter: true,
+			wantReleased:   model.StatusWorkingFailed,
+		},
+		{
+			name:           "permanent errors are never parked",
+			retryCount:     1,
+			legacyErr:      retry.Permanent(errUnavailable),
+			wantCalls:      1,
+			wantDeadLetter: true,
+			wantReleased:   model.StatusWorkingFailed,
+		},
+		{
+			name:         "parked behind the employee's earlier records",
+			parkAttempts: 1,
+			hasParked:    true,
+			wantReleased: model.StatusWorkingParked,
+			wantParked:   true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "failing to park keeps the record PENDING",
+			retryCount:   1,
+			legacyErr:    errUnavailable,
+			parkErr:      errors.New("db down"),
+			wantCalls:    1,
+			wantRetry:    true,
+			wantReleased: model.StatusWorkingPending,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{record: model.WorkingTime{ID: 7, EmployeeID: "emp-1", LaborStatus: model.StatusWorkingPending, LaborRetryCount: tt.retryCount, Version: 1}}
+			legacy := &fakeLegacy{err: tt.legacyErr}
+			schedules := &fakeSchedule{hasParked: tt.hasParked, parkErr: tt.parkErr}
+
+			p := NewProcessor(repo, legacy)
+			p.Retry = retry.Policy{Backoff: retry.Fixed(time.Minute), MaxAttempts: 2}
+			p.Schedule = schedules
+			p.ParkRetry = retry.Policy{Backoff: retry.Fixed(time.Hour), MaxAttempts: 3}
+
+			msg := ports.Message{ID: "m1", Body: body, ReceiveCount: 1, Attributes: map[string]string{}}
+			if tt.parkAttempts > 0 {
+				msg.Attributes[schedule.AttemptsAttribute] = strconv.Itoa(tt.parkAttempts)
+			}
+
+			start := time.Now()
+			shouldRetry, _, err := p.Process(context.Background(), msg)
+
+			if shouldRetry != tt.wantRetry {
+				t.Errorf("shouldRetry = %v, want %v (error %v)", shouldRetry, tt.wantRetry, err)
+			}
+			var deadLetter *worker.DeadLetterError
+			if got := errors.As(err, &deadLetter); got != tt.wantDeadLetter {
+				t.Errorf("dead-lettered = %v, want %v (error %v)", got, tt.wantDeadLetter, err)
+			}
+			if legacy.calls != tt.wantCalls {
+				t.Errorf("legacy API calls = %d, want %d", legacy.calls, tt.wantCalls)
+			}
+			if len(repo.released) != 1 || repo.released[0] != tt.wantReleased {
+				t.Fatalf("released as %v, want [%s]", repo.released, tt.wantReleased)
+			}
+
+			if !tt.wantParked {
+				if len(schedules.parked) != 0 {
+					t.Errorf("parked %+v, want nothing", schedules.parked)
+				}
+				return
+			}
+			if len(schedules.parked) != 1 {
+				t.Fatalf("parked %d jobs, want 1", len(schedules.parked))
+			}
+			if repo.retries[0] != 0 {
+				t.Errorf("retry count = %d, want a fresh budget", repo.retries[0])
+			}
+			job := schedules.parked[0]
+			if job.Queue != ScheduleQueue || job.WorkingTimeID != 7 || job.EmployeeID != "emp-1" || string(job.Body) != string(body) {
+				t.Errorf("parked job = %+v, want the message of working time 7", job)
+			}
+			if job.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", job.Attempts, tt.wantAttempts)
+			}
+			if next := job.NextAttemptAt.Sub(start); next < tt.wantNextAfter || next > tt.wantNextAfter+time.Minute {
+				t.Errorf("NextAttemptAt is %v from now, want %v", next, tt.wantNextAfter)
+			}
+		})
+	}
+}
+
+func TestProcessRetriesWhenCompletionIsNotStored(t *testing.T) {
+	body, err := messaging.Encode(messaging.CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	repo := &fakeRepo{
+		record:     model.WorkingTime{ID: 7, EmployeeID: "emp-1", LaborStatus: model.StatusWorkingPending, Version: 1},
+		releaseErr: errors.New("connection reset"),
+	}
+	legacy := &fakeLegacy{}
+
+	shouldRetry, _, err := NewProcessor(repo, legacy).Process(context.Background(), ports.Message{ID: "m1", Body: body, ReceiveCount: 1})
+
+	if legacy.calls != 1 {
+		t.Fatalf("legacy API calls = %d, want 1", legacy.calls)
+	}
+	if !shouldRetry || err == nil {
+		t.Errorf("Process() = (%v, %v), want a retry with the database error", shouldRetry, err)
+	}
+}
+
+func TestProcessSendsOnlyTheLatestVersion(t *testing.T) {
+	checkOut := messaging.CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1}
+	v1, err := messaging.Encode(checkOut)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	corrected := checkOut
+	corrected.HoursWorked = 7.5
+	corrected.Version = 2
+	v2, err := messaging.Encode(messaging.ShiftCorrectedEvent{CheckOutEvent: corrected, DisputeID: 3, CorrectedBy: "sup-7"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// The CheckedOut event of version 1 is still queued when the shift is corrected
+	// to version 2, which leaves the record PENDING.
+	repo := &fakeRepo{record: model.WorkingTime{ID: 7, EmployeeID: "emp-1", LaborStatus: model.StatusWorkingPending, Version: 2}}
+	legacy := &fakeLegacy{}
+	p := NewProcessor(repo, legacy)
+	process := func(body []byte) {
+		t.Helper()
+		if shouldRetry, _, err := p.Process(context.Background(), ports.Message{ID: "m1", Body: body, ReceiveCount: 1}); shouldRetry || err != nil {
+			t.Fatalf("Process() = (%v, %v), want the message acked", shouldRetry, err)
+		}
+	}
+
+	process(v1)
+	if legacy.calls != 0 || len(repo.released) != 0 {
+		t.Fatalf("version 1 was sent %d times and released as %v, want it skipped", legacy.calls, repo.released)
+	}
+
+	process(v2)
+	if len(legacy.sent) != 1 || legacy.sent[0].Version != 2 || legacy.sent[0].HoursWorked != 7.5 {
+		t.Fatalf("sent %+v, want the corrected shift", legacy.sent)
+	}
+	if repo.record.LaborStatus != model.StatusWorkingCompleted {
+		t.Errorf("labor status = %s, want COMPLETED", repo.record.LaborStatus)
+	}
+
+	// A redelivery of version 1 after the correction was sent is skipped as well.
+	process(v1)
+	if legacy.calls != 1 {
+		t.Errorf("legacy API calls = %d, want 1", legacy.calls)
+	}
+}
+
+// correctedRepo corrects its record right after it was read.
+type correctedRepo struct {
+	fakeRepo
+}
+
+func (r *correctedRepo) GetCheckInOut(ctx context.Context, id int64) (*model.WorkingTime, error) {
+	record, err := r.fakeRepo.GetCheckInOut(ctx, id)
+	r.record.Version++
+	return record, err
+}
+
+func TestProcessDoesNotCompleteACorrectionWithOldTimes(t *testing.T) {
+	body, err := messaging.Encode(messaging.CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	// The record was read at version 1 and corrected before the lease was taken.
+	repo := &correctedRepo{fakeRepo: fakeRepo{record: model.WorkingTime{ID: 7, EmployeeID: "emp-1", LaborStatus: model.StatusWorkingPending, Version: 1}}}
+	legacy := &fakeLegacy{}
+
+	shouldRetry, _, _ := NewProcessor(repo, legacy).Process(context.Background(), ports.Message{ID: "m1", Body: body, ReceiveCount: 1})
+
+	if legacy.calls != 0 {
+		t.Errorf("legacy API calls = %d, want 0", legacy.calls)
+	}
+	if !shouldRetry {
+		t.Error("shouldRetry = false, want the message looked at again")
+	}
+	if len(repo.released) != 0 {
+		t.Errorf("released as %v, want the corrected record left alone", repo.released)
+	}
+}
+
+func TestProcessSkipsRetriedCheckOutAfterCorrection(t *testing.T) {
+	// On Kafka a retried CheckedOut goes through the retry topic and can arrive after
+	// the correction that replaced it was sent and COMPLETED.
+	body, err := messaging.Encode(messaging.CheckOutEvent{WorkingTimeID: 7, EmployeeID: "emp-1", HoursWorked: 8, Version: 1})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	repo := &fakeRepo{record: model.WorkingTime{ID: 7, EmployeeID: "emp-1", LaborStatus: model.StatusWorkingCompleted, Version: 2}}
+	legacy := &fakeLegacy{}
+
+	shouldRetry, _, err := NewProcessor(repo, legacy).Process(context.Background(), ports.Message{ID: "m1", Body: body, ReceiveCount: 3})
+
+	if shouldRetry || err != nil {
+		t.Errorf("Process() = (%v, %v), want the retry acked", shouldRetry, err)
+	}
+	if legacy.calls != 0 || len(repo.released) != 0 {
+		t.Errorf("sent %d times and released as %v, want the retry skipped", legacy.calls, repo.released)
+	}
+}
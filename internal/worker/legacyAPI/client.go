@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/worker/retry"
 	"github.com/rs/zerolog/log"
 )
 
@@ -53,7 +54,8 @@ func (c *HTTPClient) RecordCheckOut(ctx context.Context, event messaging.CheckOu
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("legacy api returned non-successful status code: %d", resp.StatusCode)
+		// Rejected requests are permanent and a Retry-After header is honoured.
+		return fmt.Errorf("legacy api returned %w", retry.HTTPError(resp, time.Now()))
 	}
 
 	log.Ctx(ctx).Info().Str("employee_id", event.EmployeeID).Msg("Successfully recorded check-out in legacy system")
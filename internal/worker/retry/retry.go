@@ -0,0 +1,183 @@
+// Package retry decides whether and when a failed attempt is tried again. A Policy
+// combines a Backoff with a maximum number of attempts and honours what the error
+// says about itself: permanent errors are never retried and errors carrying a
+// Retry-After delay are retried after that delay.
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Backoff returns the delay before the retry that follows the given attempt (1-based).
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// Exponential doubles the delay with every attempt, starting at Base and capped at
+// Max. With FullJitter the delay is drawn uniformly from [0, delay), so clients that
+// failed together do not retry together.
+type Exponential struct {
+	Base       time.Duration
+	Max        time.Duration
+	FullJitter bool
+
+	// randN returns a random number in [0, n); nil uses math/rand.
+	randN func(n int64) int64
+}
+
+func (e Exponential) Delay(attempt int) time.Duration {
+	delay := e.Base
+	for i := 1; i < attempt && delay < e.Max; i++ {
+		delay *= 2
+	}
+	delay = min(delay, e.Max)
+
+	if !e.FullJitter || delay <= 0 {
+		return delay
+	}
+	randN := e.randN
+	if randN == nil {
+		randN = rand.Int64N
+	}
+	return time.Duration(randN(int64(delay)))
+}
+
+// Fixed waits the same delay before every retry.
+type Fixed time.Duration
+
+func (f Fixed) Delay(int) time.Duration {
+	return time.Duration(f)
+}
+
+// Policy decides whether and when a failed attempt is retried.
+type Policy struct {
+	Backoff Backoff
+	// MaxAttempts is the total number of attempts, including the first. Zero means
+	// no limit, e.g. when the queue's redrive policy is the limit.
+	MaxAttempts int
+	// MaxDelay caps the delay Next returns, including a Retry-After delay a server
+	// asked for. Zero means MaxDelay, the longest delay the queues can hold a message.
+	MaxDelay time.Duration
+}
+
+// MaxDelay is the longest retry delay: SQS cannot hide a message for more than 12
+// hours, so a longer Retry-After would fail the retry instead of delaying it.
+const MaxDelay = 12 * time.Hour
+
+// NewExponential returns a policy that backs off exponentially with full jitter from
+// base up to maxDelay and allows maxAttempts attempts. A Retry-After delay is capped
+// at maxDelay as well.
+func NewExponential(base, maxDelay time.Duration, maxAttempts int) Policy {
+	return Policy{
+		Backoff:     Exponential{Base: base, Max: maxDelay, FullJitter: true},
+		MaxAttempts: maxAttempts,
+		MaxDelay:    maxDelay,
+	}
+}
+
+// Next returns the delay before retrying after the given attempt (1-based) failed
+// with err, and false if it must not be retried: err is permanent or the attempts
+// are used up. A Retry-After delay carried by err takes precedence over the backoff;
+// either is capped at MaxDelay.
+func (p Policy) Next(attempt int, err error) (time.Duration, bool) {
+	if IsPermanent(err) {
+		return 0, false
+	}
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	limit := MaxDelay
+	if p.MaxDelay > 0 {
+		limit = min(p.MaxDelay, MaxDelay)
+	}
+	if d, ok := RetryAfter(err); ok {
+		return min(d, limit), true
+	}
+	if p.Backoff == nil {
+		return 0, true
+	}
+	return min(p.Backoff.Delay(attempt), limit), true
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable, e.g. a request the server rejected as invalid.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// retryAfterError carries the delay a server asked for before the next attempt.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// After marks err as retryable after delay, e.g. from a Retry-After header.
+func After(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// RetryAfter returns the delay attached to err with After.
+func RetryAfter(err error) (time.Duration, bool) {
+	var r *retryAfterError
+	if errors.As(err, &r) {
+		return r.delay, true
+	}
+	return 0, false
+}
+
+// HTTPError returns the error for a response with a non-successful status code,
+// classified for a Policy: a Retry-After header is honoured, and 4xx responses other
+// than 408 and 429 are permanent since sending the same request again cannot help.
+func HTTPError(resp *http.Response, now time.Time) error {
+	err := fmt.Errorf("non-successful status code: %d", resp.StatusCode)
+
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		return After(err, d)
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
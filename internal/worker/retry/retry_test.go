@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExponentialDelay(t *testing.T) {
+	e := Exponential{Base: 10 * time.Second, Max: time.Minute}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{50, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := e.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestExponentialFullJitter(t *testing.T) {
+	var ceiling int64
+	e := Exponential{
+		Base:       10 * time.Second,
+		Max:        time.Minute,
+		FullJitter: true,
+		randN: func(n int64) int64 {
+			ceiling = n
+			return n / 2
+		},
+	}
+
+	if got := e.Delay(2); got != 10*time.Second {
+		t.Errorf("Delay(2) = %v, want 10s", got)
+	}
+	if time.Duration(ceiling) != 20*time.Second {
+		t.Errorf("jitter drawn below %v, want 20s", time.Duration(ceiling))
+	}
+
+	// Without a stubbed source the delay stays within [0, cap).
+	e.randN = nil
+	for range 100 {
+		if got := e.Delay(10); got < 0 || got >= time.Minute {
+			t.Fatalf("Delay(10) = %v, want within [0, 1m)", got)
+		}
+	}
+}
+
+func TestPolicyNext(t *testing.T) {
+	errFailed := errors.New("failed")
+	p := Policy{Backoff: Fixed(5 * time.Second), MaxAttempts: 3}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		err       error
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{"transient", 1, errFailed, 5 * time.Second, true},
+		{"last attempt", 3, errFailed, 0, false},
+		{"permanent", 1, Permanent(errFailed), 0, false},
+		{"retry after", 2, After(errFailed, time.Minute), time.Minute, true},
+		{"retry after exhausted", 3, After(errFailed, time.Minute), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := p.Next(tt.attempt, tt.err)
+			if delay != tt.wantDelay || retry != tt.wantRetry {
+				t.Errorf("Next(%d) = (%v, %v), want (%v, %v)", tt.attempt, delay, retry, tt.wantDelay, tt.wantRetry)
+			}
+		})
+	}
+
+	capped := Policy{Backoff: Fixed(time.Second), MaxDelay: time.Hour}
+	if delay, _ := capped.Next(1, After(errFailed, 48*time.Hour)); delay != time.Hour {
+		t.Errorf("Retry-After beyond MaxDelay: delay = %v, want %v", delay, time.Hour)
+	}
+	if delay, _ := p.Next(1, After(errFailed, 48*time.Hour)); delay != MaxDelay {
+		t.Errorf("Retry-After without MaxDelay: delay = %v, want %v", delay, MaxDelay)
+	}
+	if delay, _ := NewExponential(time.Second, time.Minute, 5).Next(1, After(errFailed, time.Hour)); delay != time.Minute {
+		t.Errorf("NewExponential Retry-After: delay = %v, want %v", delay, time.Minute)
+	}
+
+	unlimited := Policy{Backoff: Fixed(time.Second)}
+	if _, retry := unlimited.Next(1000, errFailed); !retry {
+		t.Error("policy without MaxAttempts stopped retrying")
+	}
+}
+
+func TestClassificationSurvivesWrapping(t *testing.T) {
+	err := errors.Join(errors.New("context"), Permanent(errors.New("bad request")))
+	if !IsPermanent(err) {
+		t.Error("IsPermanent lost the mark through wrapping")
+	}
+
+	if _, ok := RetryAfter(errors.New("plain")); ok {
+		t.Error("RetryAfter found a delay on a plain error")
+	}
+	if Permanent(nil) != nil || After(nil, time.Second) != nil {
+		t.Error("marking a nil error must return nil")
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		status        int
+		retryAfter    string
+		wantPermanent bool
+		wantDelay     time.Duration
+		wantHasDelay  bool
+	}{
+		{"server error", http.StatusInternalServerError, "", false, 0, false},
+		{"bad request", http.StatusBadRequest, "", true, 0, false},
+		{"request timeout", http.StatusRequestTimeout, "", false, 0, false},
+		{"too many requests", http.StatusTooManyRequests, "", false, 0, false},
+		{"retry after seconds", http.StatusServiceUnavailable, "120", false, 2 * time.Minute, true},
+		{"retry after date", http.StatusTooManyRequests, now.Add(30 * time.Second).Format(http.TimeFormat), false, 30 * time.Second, true},
+		{"retry after in the past", http.StatusTooManyRequests, now.Add(-time.Minute).Format(http.TimeFormat), false, 0, true},
+		{"invalid retry after", http.StatusServiceUnavailable, "soon", false, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			err := HTTPError(resp, now)
+			if got := IsPermanent(err); got != tt.wantPermanent {
+				t.Errorf("IsPermanent = %v, want %v", got, tt.wantPermanent)
+			}
+			delay, ok := RetryAfter(err)
+			if ok != tt.wantHasDelay || delay != tt.wantDelay {
+				t.Errorf("RetryAfter = (%v, %v), want (%v, %v)", delay, ok, tt.wantDelay, tt.wantHasDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package schedule re-enqueues messages that were parked in the retry schedule
+// because their retries ran out during an outage of the system they are sent to.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker"
+	"github.com/rs/zerolog/log"
+)
+
+// AttemptsAttribute is the message attribute that carries how many times a
+// re-enqueued message has been parked before.
+const AttemptsAttribute = "schedule_attempts"
+
+// Attempts returns how many times msg has been parked before, or 0 if it was never parked.
+func Attempts(msg ports.Message) int {
+	n, _ := strconv.Atoi(msg.Attributes[AttemptsAttribute])
+	return n
+}
+
+// Scheduler sends due jobs of one queue's retry schedule back to the queue. All jobs
+// of an employee are sent together, in the order they were parked, so the events of
+// one employee keep their order. Sending is throttled to Rate jobs per second and
+// follows the health of the downstream system, so a recovering system is not flooded
+// with the backlog of a long outage.
+type Scheduler struct {
+	repo        repository.RetryScheduleRepository
+	sender      ports.MessageSender
+	queue       string
+	destination string
+
+	// Health, if set, is the downstream system the queue's processor calls. Nothing
+	// is sent while it is down and one employee's jobs per poll while it is degraded.
+	Health worker.HealthReporter
+	// Rate is how many jobs are sent per second at most.
+	Rate int
+	// PollInterval is how often due jobs are looked up.
+	PollInterval time.Duration
+	// BatchSize is how many employees' jobs are claimed per poll.
+	BatchSize int
+	// Lease is how long claimed jobs are hidden from other schedulers; it must be
+	// longer than sending a batch at Rate.
+	Lease time.Duration
+}
+
+// NewScheduler creates a scheduler for the jobs parked for queue, which it sends to
+// destination through sender.
+func NewScheduler(repo repository.RetryScheduleRepository, sender ports.MessageSender, queue, destination string) *Scheduler {
+	return &Scheduler{
+		repo:         repo,
+		sender:       sender,
+		queue:        queue,
+		destination:  destination,
+		Rate:         5,
+		PollInterval: 10 * time.Second,
+		BatchSize:    20,
+		Lease:        5 * time.Minute,
+	}
+}
+
+// Run sends due jobs until ctx is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Info().Str("queue", s.queue).Msg("Retry scheduler started")
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Str("queue", s.queue).Msg("Retry scheduler stopped")
+			return
+		case <-ticker.C:
+			s.sendDue(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) sendDue(ctx context.Context) {
+	employees := s.BatchSize
+	if s.Health != nil {
+		switch s.Health.Health() {
+		case worker.HealthDown:
+			return
+		case worker.HealthDegraded:
+			employees = 1
+		}
+	}
+
+	jobs, err := s.repo.ClaimDue(ctx, s.queue, employees, s.Lease)
+	if err != nil {
+		log.Error().Err(err).Str("queue", s.queue).Msg("Failed to claim scheduled retries")
+		return
+	}
+
+	interval := time.Second / time.Duration(max(s.Rate, 1))
+	var failedEmployee string
+	for i, job := range jobs {
+		if job.EmployeeID == failedEmployee {
+			// Sending a later job would let it overtake the one that failed; the
+			// rest of the employee's jobs go again once the lease ends.
+			continue
+		}
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+
+		if err := s.send(ctx, job); err != nil {
+			log.Error().Err(err).Int64("working_time_id", job.WorkingTimeID).Msg("Failed to re-enqueue scheduled retry")
+			failedEmployee = job.EmployeeID
+			continue
+		}
+		log.Info().Int64("working_time_id", job.WorkingTimeID).Int("attempts", job.Attempts).Str("queue", s.queue).Msg("Re-enqueued scheduled retry")
+	}
+}
+
+// send puts a job back on the queue and removes it from the schedule.
+func (s *Scheduler) send(ctx context.Context, job model.ScheduledRetry) error {
+	opts := []ports.SendOption{
+		ports.WithOrderingKey(job.EmployeeID),
+		ports.WithDeduplicationID(fmt.Sprintf("retry-%d-%d", job.ID, job.Attempts)),
+		ports.WithAttribute(AttemptsAttribute, strconv.Itoa(job.Attempts)),
+	}
+	if eventType := job.Attributes[messaging.EventTypeAttribute]; eventType != "" {
+		opts = append(opts, ports.WithAttribute(messaging.EventTypeAttribute, eventType))
+	}
+
+	if err := s.sender.SendMessage(ctx, s.destination, job.Body, opts...); err != nil {
+		return err
+	}
+	return s.repo.Remove(ctx, job.ID)
+}
@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker"
+)
+
+// fakeSchedule hands out its jobs on the first ClaimDue and records removals.
+type fakeSchedule struct {
+	repository.RetryScheduleRepository
+	jobs    []model.ScheduledRetry
+	claimed []int // employees argument of each ClaimDue call
+	removed []int64
+}
+
+func (s *fakeSchedule) ClaimDue(ctx context.Context, queue string, employees int, lease time.Duration) ([]model.ScheduledRetry, error) {
+	s.claimed = append(s.claimed, employees)
+	jobs := s.jobs
+	s.jobs = nil
+	return jobs, nil
+}
+
+func (s *fakeSchedule) Remove(ctx context.Context, id int64) error {
+	s.removed = append(s.removed, id)
+	return nil
+}
+
+// sentMessage is a message sent through fakeSender.
+type sentMessage struct {
+	destination string
+	body        string
+	options     ports.SendOptions
+}
+
+// fakeSender records sent messages; bodies in fail are rejected.
+type fakeSender struct {
+	sent []sentMessage
+	fail []string
+}
+
+func (s *fakeSender) SendMessage(ctx context.Context, destination string, body []byte, opts ...ports.SendOption) error {
+	if slices.Contains(s.fail, string(body)) {
+		return errors.New("send failed")
+	}
+	s.sent = append(s.sent, sentMessage{destination: destination, body: string(body), options: ports.ApplySendOptions(opts)})
+	return nil
+}
+
+type fixedHealth worker.Health
+
+func (h fixedHealth) Health() worker.Health { return worker.Health(h) }
+
+func job(id int64, employeeID string, attempts int) model.ScheduledRetry {
+	return model.ScheduledRetry{
+		ID:            id,
+		Queue:         "labor",
+		WorkingTimeID: id * 10,
+		EmployeeID:    employeeID,
+		Body:          []byte{byte('a' + id - 1)},
+		Attempts:      attempts,
+	}
+}
+
+func TestSendDue(t *testing.T) {
+	tests := []struct {
+		name        string
+		jobs        []model.ScheduledRetry
+		health      worker.Health
+		fail        []string
+		wantClaimed []int
+		wantSent    []string
+		wantRemoved []int64
+	}{
+		{
+			name:        "sends jobs in order and removes them",
+			jobs:        []model.ScheduledRetry{job(1, "emp-1", 1), job(2, "emp-1", 2), job(3, "emp-2", 1)},
+			wantClaimed: []int{20},
+			wantSent:    []string{"a", "b", "c"},
+			wantRemoved: []int64{1, 2, 3},
+		},
+		{
+			name:        "failed send holds back the rest of the employee",
+			jobs:        []model.ScheduledRetry{job(1, "emp-1", 1), job(2, "emp-1", 1), job(3, "emp-2", 1)},
+			fail:        []string{"a"},
+			wantClaimed: []int{20},
+			wantSent:    []string{"c"},
+			wantRemoved: []int64{3},
+		},
+		{
+			name:        "degraded downstream gets one employee",
+			jobs:        []model.ScheduledRetry{job(1, "emp-1", 1)},
+			health:      worker.HealthDegraded,
+			wantClaimed: []int{1},
+			wantSent:    []string{"a"},
+			wantRemoved: []int64{1},
+		},
+		{
+			name:   "downstream down sends nothing",
+			jobs:   []model.ScheduledRetry{job(1, "emp-1", 1)},
+			health: worker.HealthDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSchedule{jobs: tt.jobs}
+			sender := &fakeSender{fail: tt.fail}
+			s := NewScheduler(repo, sender, "labor", "labor-queue")
+			s.Health = fixedHealth(tt.health)
+			s.Rate = 1000
+
+			s.sendDue(context.Background())
+
+			if !slices.Equal(repo.claimed, tt.wantClaimed) {
+				t.Errorf("ClaimDue employees = %v, want %v", repo.claimed, tt.wantClaimed)
+			}
+			var sent []string
+			for _, m := range sender.sent {
+				sent = append(sent, m.body)
+			}
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if !slices.Equal(repo.removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", repo.removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestSendCarriesAttemptsAndOrderingKey(t *testing.T) {
+	repo := &fakeSchedule{}
+	sender := &fakeSender{}
+	s := NewScheduler(repo, sender, "labor", "labor-queue")
+
+	j := job(1, "emp-1", 3)
+	j.Attributes = map[string]string{messaging.EventTypeAttribute: messaging.TypeCheckedOut}
+	if err := s.send(context.Background(), j); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sender.sent))
+	}
+	m := sender.sent[0]
+	if m.destination != "labor-queue" {
+		t.Errorf("destination = %q, want %q", m.destination, "labor-queue")
+	}
+	if m.options.OrderingKey != "emp-1" {
+		t.Errorf("ordering key = %q, want %q", m.options.OrderingKey, "emp-1")
+	}
+	if m.options.DeduplicationID != "retry-1-3" {
+		t.Errorf("deduplication ID = %q, want %q", m.options.DeduplicationID, "retry-1-3")
+	}
+	if got := Attempts(ports.Message{Attributes: m.options.Attributes}); got != 3 {
+		t.Errorf("Attempts() of the sent message = %d, want 3", got)
+	}
+	if got := m.options.Attributes[messaging.EventTypeAttribute]; got != messaging.TypeCheckedOut {
+		t.Errorf("event type attribute = %q, want %q", got, messaging.TypeCheckedOut)
+	}
+}
+
+func TestSendKeepsJobWhenSendFails(t *testing.T) {
+	repo := &fakeSchedule{}
+	s := NewScheduler(repo, &fakeSender{fail: []string{"a"}}, "labor", "labor-queue")
+
+	if err := s.send(context.Background(), job(1, "emp-1", 1)); err == nil {
+		t.Fatal("send() error = nil, want the send error")
+	}
+	if len(repo.removed) != 0 {
+		t.Errorf("removed = %v, want the job kept in the schedule", repo.removed)
+	}
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+	"checkin.service/internal/worker/retry"
+	"checkin.service/pkg/egress"
+	"github.com/rs/zerolog/log"
+	"github.com/sony/gobreaker"
+)
+
+// Request headers of a delivery. Subscribers verify SignatureHeader with Sign and
+// can use EventIDHeader to drop an event they have already handled.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventIDHeader   = "X-Webhook-Event-Id"
+	EventTypeHeader = "X-Webhook-Event-Type"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Dispatcher sends pending webhook deliveries. A failed delivery is retried as the
+// Retry policy decides, and each subscriber has its own circuit
+// breaker, so an unreachable subscriber is left alone for a while instead of
+// using up its attempts.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+
+	// Retry decides when a failed delivery is tried again. Its MaxAttempts is how many
+	// times a delivery is tried before it is marked FAILED.
+	Retry retry.Policy
+	// PollInterval is how often due deliveries are looked up, BatchSize how many are
+	// sent concurrently.
+	PollInterval time.Duration
+	BatchSize    int
+	// BreakerTimeout is how long a subscriber's breaker stays open before a trial delivery.
+	BreakerTimeout time.Duration
+
+	mu       sync.Mutex
+	breakers map[int64]*gobreaker.CircuitBreaker
+}
+
+// NewDispatcher creates a dispatcher whose HTTP calls time out after timeout. Unless
+// allowPrivate is set, it refuses to connect to loopback, private and other internal
+// addresses, whatever a subscription's host resolves to at delivery time.
+func NewDispatcher(repo repository.WebhookRepository, timeout time.Duration, allowPrivate bool) *Dispatcher {
+	return &Dispatcher{
+		repo:           repo,
+		client:         egress.NewClient(timeout, allowPrivate),
+		Retry:          retry.NewExponential(30*time.Second, time.Hour, 8),
+		PollInterval:   time.Second,
+		BatchSize:      20,
+		BreakerTimeout: time.Minute,
+		breakers:       make(map[int64]*gobreaker.CircuitBreaker),
+	}
+}
+
+// Run sends due deliveries until ctx is canceled. A batch that is being sent when
+// ctx is canceled is finished first.
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Info().Msg("Webhook dispatcher started")
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.dispatchDue(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	// The lease must outlast a batch, so it is twice the HTTP timeout.
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.BatchSize, 2*d.client.Timeout)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to claim webhook deliveries")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			d.dispatch(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// dispatch makes one attempt at a delivery and records the outcome.
+func (d *Dispatcher) dispatch(ctx context.Context, delivery *model.WebhookDelivery) {
+	now := time.Now().UTC()
+	logger := log.With().Int64("delivery_id", delivery.ID).Int64("subscription_id", delivery.SubscriptionID).Logger()
+
+	var statusCode int
+	_, err := d.breaker(delivery.SubscriptionID).Execute(func() (interface{}, error) {
+		var err error
+		statusCode, err = d.post(ctx, delivery, now)
+		return nil, err
+	})
+
+	switch {
+	case err == nil:
+		delivery.Status = model.StatusDeliveryDelivered
+		delivery.Attempts++
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		delivery.LastStatusCode = statusCode
+	case errors.Is(err, gobreaker.ErrOpenState), errors.Is(err, gobreaker.ErrTooManyRequests):
+		// The subscriber was not called, so the attempt does not count and the status
+		// code of the last real attempt is kept.
+		delivery.NextAttemptAt = now.Add(d.BreakerTimeout)
+		delivery.LastError = "circuit breaker open"
+	default:
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		delivery.LastStatusCode = statusCode
+		if delay, ok := d.Retry.Next(delivery.Attempts, err); ok {
+			delivery.NextAttemptAt = now.Add(delay)
+		} else {
+			delivery.Status = model.StatusDeliveryFailed
+		}
+	}
+
+	if err := d.repo.RecordDeliveryAttempt(ctx, delivery); err != nil {
+		logger.Error().Err(err).Msg("Failed to record webhook delivery attempt")
+		return
+	}
+
+	switch delivery.Status {
+	case model.StatusDeliveryDelivered:
+		logger.Info().Str("event_id", delivery.EventID).Msg("Webhook delivered")
+	case model.StatusDeliveryFailed:
+		logger.Error().Str("event_id", delivery.EventID).Int("attempts", delivery.Attempts).Msg("Webhook delivery failed permanently")
+	default:
+		logger.Warn().Str("event_id", delivery.EventID).Str("error", delivery.LastError).Time("next_attempt_at", delivery.NextAttemptAt).Msg("Webhook delivery will be retried")
+	}
+}
+
+// post sends the event to the subscriber and returns the response status code.
+func (d *Dispatcher) post(ctx context.Context, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %w", retry.HTTPError(resp, now))
+	}
+	return resp.StatusCode, nil
+}
+
+// breaker returns the circuit breaker of a subscription. It trips after 5
+// consecutive failures.
+func (d *Dispatcher) breaker(subscriptionID int64) *gobreaker.CircuitBreaker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cb, ok := d.breakers[subscriptionID]
+	if !ok {
+		cb = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        "webhook-" + strconv.FormatInt(subscriptionID, 10),
+			MaxRequests: 1,
+			Timeout:     d.BreakerTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= 5
+			},
+		})
+		d.breakers[subscriptionID] = cb
+	}
+	return cb
+}
+
+// Sign returns the signature of a delivery: "sha256=" followed by the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription's secret. Including the
+// timestamp lets subscribers reject replayed requests.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
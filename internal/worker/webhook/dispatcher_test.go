@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"checkin.service/internal/core/model"
+	"checkin.service/internal/ports/repository"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "known vector",
+			secret:    "whsec-0123456789abcdef",
+			timestamp: "1700000000",
+			body:      `{"id":"checkout-1-v1"}`,
+			want:      "sha256=c0e7b5b2901e3077eeb8fe6f1bfc32c49e1e6149424ffa736cfacc2e5ff03233",
+		},
+		{
+			name:      "empty body",
+			secret:    "whsec-0123456789abcdef",
+			timestamp: "1700000000",
+			body:      "",
+			want:      Sign("whsec-0123456789abcdef", "1700000000", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// The timestamp and the secret are part of the signature.
+	base := Sign("whsec-0123456789abcdef", "1700000000", []byte("body"))
+	if Sign("whsec-0123456789abcdef", "1700000001", []byte("body")) == base {
+		t.Error("Sign() ignores the timestamp")
+	}
+	if Sign("whsec-fedcba9876543210", "1700000000", []byte("body")) == base {
+		t.Error("Sign() ignores the secret")
+	}
+}
+
+// recordingRepo records the delivery attempts of a Dispatcher.
+type recordingRepo struct {
+	repository.WebhookRepository
+	recorded []model.WebhookDelivery
+}
+
+func (r *recordingRepo) RecordDeliveryAttempt(ctx context.Context, d *model.WebhookDelivery) error {
+	r.recorded = append(r.recorded, *d)
+	return nil
+}
+
+func TestDispatchKeepsStatusCodeWhileBreakerOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	repo := &recordingRepo{}
+	d := NewDispatcher(repo, time.Second, true)
+	delivery := &model.WebhookDelivery{ID: 1, SubscriptionID: 1, URL: server.URL, Secret: "whsec-0123456789abcdef"}
+
+	// Five failures trip the subscriber's breaker; the sixth attempt is skipped.
+	for range 6 {
+		d.dispatch(context.Background(), delivery)
+	}
+
+	if len(repo.recorded) != 6 {
+		t.Fatalf("recorded %d attempts, want 6", len(repo.recorded))
+	}
+	skipped := repo.recorded[5]
+	if skipped.LastError != "circuit breaker open" {
+		t.Fatalf("LastError = %q, want the breaker to be open", skipped.LastError)
+	}
+	if skipped.Attempts != 5 {
+		t.Errorf("Attempts = %d, want 5", skipped.Attempts)
+	}
+	if skipped.LastStatusCode != http.StatusBadGateway {
+		t.Errorf("LastStatusCode = %d, want %d from the last real attempt", skipped.LastStatusCode, http.StatusBadGateway)
+	}
+}
+
+func TestDispatchRefusesInternalAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	repo := &recordingRepo{}
+	d := NewDispatcher(repo, time.Second, false)
+	d.dispatch(context.Background(), &model.WebhookDelivery{ID: 1, SubscriptionID: 1, URL: server.URL})
+
+	if called {
+		t.Error("dispatcher called a loopback address")
+	}
+	if len(repo.recorded) != 1 || repo.recorded[0].Attempts != 1 {
+		t.Errorf("recorded %+v, want one failed attempt", repo.recorded)
+	}
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"checkin.service/internal/ports"
+	"checkin.service/internal/ports/messaging"
+	"checkin.service/internal/ports/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// Processor handles messages from the events queue. It does not call subscribers
+// itself: it records a pending delivery per subscription to the event's type, which
+// the Dispatcher then sends. A slow or failing subscriber therefore never holds up
+// the queue or the other subscribers.
+type Processor struct {
+	repo repository.WebhookRepository
+}
+
+// NewProcessor creates a new processor for the events queue.
+func NewProcessor(repo repository.WebhookRepository) *Processor {
+	return &Processor{repo: repo}
+}
+
+// Process fans an event out into one delivery per subscriber.
+func (p *Processor) Process(ctx context.Context, msg ports.Message) (bool, int32, error) {
+	env, err := messaging.Decode(msg.Body)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to unmarshal event")
+		return false, 0, err // Do not retry on malformed message
+	}
+	if env.Type == "" {
+		// Bare legacy payloads carry no type to subscribe to.
+		log.Ctx(ctx).Warn().Str("message_id", msg.ID).Msg("Skipping event without envelope")
+		return false, 0, nil
+	}
+
+	subs, err := p.repo.ListSubscribers(ctx, env.Type)
+	if err != nil {
+		return true, 10, fmt.Errorf("failed to load webhook subscribers: %w", err)
+	}
+
+	for _, sub := range subs {
+		if err := p.repo.EnqueueDelivery(ctx, sub.ID, env.ID, env.Type, msg.Body); err != nil {
+			// Deliveries already enqueued are skipped on the retry.
+			return true, 10, fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+		}
+	}
+
+	log.Ctx(ctx).Info().Str("event_type", env.Type).Int("subscribers", len(subs)).Msg("Enqueued webhook deliveries")
+	return false, 0, nil
+}
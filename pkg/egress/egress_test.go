@@ -0,0 +1,76 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "224.0.0.1", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{name: "public address", url: "https://93.184.216.34/hook"},
+		{name: "not http", url: "file:///etc/passwd", wantErr: ErrInvalidURL},
+		{name: "relative", url: "/hook", wantErr: ErrInvalidURL},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", wantErr: ErrForbiddenAddress},
+		{name: "localhost", url: "http://localhost/hook", wantErr: ErrForbiddenAddress},
+		{name: "metadata service", url: "http://169.254.169.254/latest/meta-data", wantErr: ErrForbiddenAddress},
+		{name: "private allowed", url: "http://10.0.0.5/hook", allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), tt.url, tt.allowPrivate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckURL(%s) error = %v, want %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := NewClient(time.Second, false).Get(server.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Get(%s) error = %v, want %v", server.URL, err, ErrForbiddenAddress)
+	}
+	resp, err := NewClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get(%s) with private networks allowed: %v", server.URL, err)
+	}
+	resp.Body.Close()
+}
@@ -2,7 +2,6 @@ package telemetry
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -52,30 +51,27 @@ func InitTracer(serviceName string) (func(context.Context) error, error) {
 	return tp.Shutdown, nil
 }
 
-// StartSpanFromSQSMessage extracts trace context from SQS message attributes and starts a new span.
-func StartSpanFromSQSMessage(ctx context.Context, msg types.Message) (context.Context, trace.Span) {
-	// Extract trace context from SQS message attributes
-	carrier := sqsCarrier{attrs: msg.MessageAttributes}
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+// StartSpanFromMessage extracts trace context from a message's attributes and starts a new span.
+// system names the broker the message came from. employeeID, if known, enriches the
+// span and the context.
+func StartSpanFromMessage(ctx context.Context, system, messageID string, attributes map[string]string, employeeID string) (context.Context, trace.Span) {
+	// Extract trace context from the message attributes
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(attributes))
 
-	tracer := otel.Tracer("sqs-worker")
+	tracer := otel.Tracer("queue-worker")
 	ctx, span := tracer.Start(ctx, "process_message",
 		trace.WithSpanKind(trace.SpanKindConsumer),
 		trace.WithAttributes(
-			attribute.String("messaging.system", "aws_sqs"),
-			attribute.String("messaging.message_id", *msg.MessageId),
+			attribute.String("messaging.message_id", messageID),
 		),
 	)
+	if system != "" {
+		span.SetAttributes(attribute.String("messaging.system", system))
+	}
 
-	// Attempt to extract employee_id from the message body to enrich the trace
-	if msg.Body != nil {
-		var payload struct {
-			EmployeeID string `json:"employeeId"`
-		}
-		if err := json.Unmarshal([]byte(*msg.Body), &payload); err == nil && payload.EmployeeID != "" {
-			span.SetAttributes(attribute.String("app.employeeId", payload.EmployeeID))
-			ctx = context.WithValue(ctx, EmployeeIDKey, payload.EmployeeID)
-		}
+	if employeeID != "" {
+		span.SetAttributes(attribute.String("app.employeeId", employeeID))
+		ctx = context.WithValue(ctx, EmployeeIDKey, employeeID)
 	}
 	return ctx, span
 }
@@ -96,7 +92,15 @@ func InjectTraceContext(ctx context.Context) map[string]types.MessageAttributeVa
 	return attrs
 }
 
-// sqsCarrier implements propagation.TextMapCarrier to extract trace context from SQS attributes.
+// InjectTraceAttributes injects the current trace context into a plain attribute map,
+// for brokers without typed message attributes.
+func InjectTraceAttributes(ctx context.Context) map[string]string {
+	attrs := make(map[string]string)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(attrs))
+	return attrs
+}
+
+// sqsCarrier implements propagation.TextMapCarrier to inject trace context into SQS attributes.
 type sqsCarrier struct {
 	attrs map[string]types.MessageAttributeValue
 }
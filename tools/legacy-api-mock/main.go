@@ -13,6 +13,13 @@ type CheckOutEvent struct {
 	EmployeeID    string    `json:"employeeId"`
 	HoursWorked   float64   `json:"hoursWorked"`
 	ClockOutTime  time.Time `json:"clockOutTime"`
+	JobLines      []JobLine `json:"jobLines"`
+}
+
+// JobLine is one per-job labor line item of a check-out
+type JobLine struct {
+	JobCode       string  `json:"jobCode"`
+	MinutesWorked float64 `json:"minutesWorked"`
 }
 
 func checkoutHandler(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +30,9 @@ func checkoutHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Received checkout for EmployeeID: %s, Hours: %.2f", event.EmployeeID, event.HoursWorked)
+	for _, line := range event.JobLines {
+		log.Printf("  Job %s: %.2f minutes", line.JobCode, line.MinutesWorked)
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
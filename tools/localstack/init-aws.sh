@@ -25,4 +25,52 @@ awslocal sqs create-queue --queue-name email-queue \
         "RedrivePolicy": "{\"deadLetterTargetArn\":\"'"$EMAIL_DLQ_ARN"'\",\"maxReceiveCount\":\"5\"}"
     }'
 
+# Supervisor notifications about working-time compliance violations, with a DLQ so
+# a notification that keeps failing is not redelivered forever
+awslocal sqs create-queue --queue-name compliance-queue-dlq
+COMPLIANCE_DLQ_ARN=$(awslocal sqs get-queue-attributes --queue-url http://localhost:4566/000000000000/compliance-queue-dlq --attribute-names QueueArn --query 'Attributes.QueueArn' --output text)
+awslocal sqs create-queue --queue-name compliance-queue \
+    --attributes '{
+        "RedrivePolicy": "{\"deadLetterTargetArn\":\"'"$COMPLIANCE_DLQ_ARN"'\",\"maxReceiveCount\":\"5\"}"
+    }'
+
+# Shift lifecycle events (checked in / out, corrected, voided) for downstream systems
+awslocal sqs create-queue --queue-name events-queue
+
+# Fan-out topic: the API publishes every event once (SNS_TOPIC_ARN) and each queue
+# receives the event types its filter policy lists.
+TOPIC_ARN=$(awslocal sns create-topic --name checkin-events --query 'TopicArn' --output text)
+
+# subscribe lets the topic send to the queue (SNS needs a queue policy for that on
+# AWS) and subscribes the queue with a filter policy on the event type.
+subscribe() {
+    local queue=$1 policy=$2
+    local queue_url="http://localhost:4566/000000000000/$queue"
+    local queue_arn subscription_arn
+    queue_arn=$(awslocal sqs get-queue-attributes --queue-url "$queue_url" --attribute-names QueueArn --query 'Attributes.QueueArn' --output text)
+    awslocal sqs set-queue-attributes --queue-url "$queue_url" \
+        --attributes '{
+            "Policy": "{\"Version\":\"2012-10-17\",\"Statement\":[{\"Effect\":\"Allow\",\"Principal\":{\"Service\":\"sns.amazonaws.com\"},\"Action\":\"sqs:SendMessage\",\"Resource\":\"'"$queue_arn"'\",\"Condition\":{\"ArnEquals\":{\"aws:SourceArn\":\"'"$TOPIC_ARN"'\"}}}]}"
+        }'
+    subscription_arn=$(awslocal sns subscribe --topic-arn "$TOPIC_ARN" --protocol sqs --notification-endpoint "$queue_arn" --query 'SubscriptionArn' --output text)
+    awslocal sns set-subscription-attributes --subscription-arn "$subscription_arn" --attribute-name FilterPolicy --attribute-value "$policy"
+}
+
+subscribe labor-queue '{"event_type": ["com.checkin-service.shift.checked-out", "com.checkin-service.shift.corrected"]}'
+subscribe email-queue '{"event_type": ["com.checkin-service.email.checkout-summary"]}'
+subscribe compliance-queue '{"event_type": ["com.checkin-service.compliance.violation"]}'
+subscribe events-queue '{"event_type": [{"prefix": "com.checkin-service.shift."}]}'
+
+echo "SNS topic $TOPIC_ARN subscribed."
+
+# FIFO variant of the labor queue, for per-employee ordering. Select it by setting
+# LABOR_SQS_QUEUE_URL and LABOR_DLQ_URL to the .fifo URLs.
+awslocal sqs create-queue --queue-name labor-queue-dlq.fifo --attributes '{"FifoQueue": "true"}'
+LABOR_FIFO_DLQ_ARN=$(awslocal sqs get-queue-attributes --queue-url http://localhost:4566/000000000000/labor-queue-dlq.fifo --attribute-names QueueArn --query 'Attributes.QueueArn' --output text)
+awslocal sqs create-queue --queue-name labor-queue.fifo \
+    --attributes '{
+        "FifoQueue": "true",
+        "RedrivePolicy": "{\"deadLetterTargetArn\":\"'"$LABOR_FIFO_DLQ_ARN"'\",\"maxReceiveCount\":\"5\"}"
+    }'
+
 echo "SQS Queues and DLQs created and configured."
\ No newline at end of file